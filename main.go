@@ -34,6 +34,13 @@ func main() {
 	featuresFilesOrDirs := flags.StringSliceP("features", "f", []string{}, "paths to .feature files or directories")
 	reporterName := flags.StringP("reporter", "r", "", "reporter (junit)")
 	outputFile := flags.StringP("output", "o", "", "output file")
+	flags.DurationVar(&steps.DefaultHTTPConfig.Timeout, "http-timeout", steps.DefaultHTTPConfig.Timeout, "maximum duration of a single HTTP request")
+	flags.DurationVar(&steps.DefaultHTTPConfig.RetryTimeout, "http-retry", steps.DefaultHTTPConfig.RetryTimeout, "maximum duration to retry failed HTTP requests (0 to disable retries)")
+	flags.StringVar(&steps.RouterAddress, "router-address", steps.RouterAddress, "address of the OpenShift router used to access the routes (defaults to the "+steps.OpenShiftRouterEnvVarName+" env var)")
+	flags.StringSliceVar(&steps.DeletableResourceTypes, "delete-kinds", steps.DeletableResourceTypes, "types of the resources deleted by the 'I delete all resources' steps")
+	flags.IntVar(&steps.DeleteGracePeriod, "grace-period", steps.DeleteGracePeriod, "period of time in seconds given to the resources to terminate gracefully when deleted (negative to use the default of each resource). Only applies to deploymentconfigs, replicationcontrollers, pods and services")
+	flags.BoolVar(&steps.SaveConfig, "save-config", steps.SaveConfig, "store the configuration of the created and replaced resources in an annotation, so that they can be applied later")
+	flags.StringVar(&steps.SchemaCacheDir, "schema-cache-dir", steps.SchemaCacheDir, "directory where the swagger schemas of the server are cached, to validate files in @offline scenarios")
 	flags.AddGoFlagSet(flag.CommandLine)
 	flags.Parse(os.Args[1:])
 
@@ -74,6 +81,9 @@ func main() {
 	}
 
 	c := steps.NewContext(&gucumber.GlobalContext)
+	if err := c.LoadFeatures(features); err != nil {
+		log.Fatalf("Failed to load the features: %v", err)
+	}
 	runner, err := c.RunFiles(features)
 	if err != nil {
 		log.Fatalf("Got error %v\n", err)
@@ -0,0 +1,153 @@
+package steps
+
+import (
+	"io/ioutil"
+
+	"k8s.io/kubernetes/pkg/runtime"
+
+	"github.com/lsegal/gucumber/gherkin"
+)
+
+// hookEnd is the kind of unit ended by a call of the After("") hook.
+// gucumber calls it at the end of each feature, background, scenario,
+// scenario outline and outline example, without telling which one ended.
+type hookEnd int
+
+const (
+	scenarioEnd hookEnd = iota
+	backgroundEnd
+	featureEnd
+)
+
+// backgroundState is the state set up by the Background of the current feature,
+// which is restored at the end of each scenario of the feature
+type backgroundState struct {
+	tunnels          map[string]*Tunnel
+	execResult       *ExecResult
+	httpState        httpState
+	processedObjects []runtime.Object
+	offline          bool
+}
+
+// LoadFeatures parses the given feature files, to know in advance which units
+// will be ended by the After("") hooks when running these files.
+// It should be called right before running the same files with RunFiles,
+// so that the state set up by the Background of a feature is kept for all its scenarios
+// (gucumber only runs the Background once, before the first scenario of the feature).
+func (c *Context) LoadFeatures(featureFiles []string) error {
+	c.hookEnds = nil
+	for _, file := range featureFiles {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		features, err := gherkin.ParseFilename(string(data), file)
+		if err != nil {
+			return err
+		}
+
+		for i := range features {
+			c.hookEnds = append(c.hookEnds, featureHookEnds(&features[i], c.Filters)...)
+		}
+	}
+	return nil
+}
+
+// featureHookEnds returns the units ended by the After("") hooks when running the given feature
+// with the given filters, in the order of the gucumber runner
+func featureHookEnds(f *gherkin.Feature, filters []string) []hookEnd {
+	if !f.FilterMatched(filters...) {
+		matched := false
+		for i := range f.Scenarios {
+			if f.Scenarios[i].FilterMatched(f, filters...) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil
+		}
+	}
+
+	var ends []hookEnd
+	if f.Background.Steps != nil && f.Background.FilterMatched(f, filters...) {
+		ends = append(ends, backgroundEnd)
+	}
+
+	for i := range f.Scenarios {
+		s := &f.Scenarios[i]
+		if !s.FilterMatched(f, filters...) {
+			continue
+		}
+
+		// the examples of an outline are run as scenarios without tags, before the end of the outline
+		if s.Examples != "" {
+			example := gherkin.Scenario{}
+			if example.FilterMatched(f, filters...) {
+				for row := 1; row < len(s.Examples.ToTable()); row++ {
+					ends = append(ends, scenarioEnd)
+				}
+			}
+		}
+		ends = append(ends, scenarioEnd)
+	}
+
+	return append(ends, featureEnd)
+}
+
+// endUnit cleans up the state at the end of a unit run by gucumber:
+// at the end of a scenario, the state is reset to the state set up by the Background of the feature (if any),
+// and at the end of a feature, the whole state is reset
+func (c *Context) endUnit() {
+	end := scenarioEnd
+	if len(c.hookEnds) > 0 {
+		end = c.hookEnds[0]
+		c.hookEnds = c.hookEnds[1:]
+	}
+
+	switch {
+	case end == backgroundEnd:
+		c.background = c.saveBackgroundState()
+	case end == featureEnd || c.background == nil:
+		c.background = nil
+		c.CloseAllTunnels()
+		c.execResult = nil
+		c.resetHTTPState()
+		c.processedObjects = nil
+		c.offline = false
+	default:
+		c.restoreBackgroundState(c.background)
+	}
+}
+
+// saveBackgroundState returns the current state, to be restored at the end of each scenario
+func (c *Context) saveBackgroundState() *backgroundState {
+	tunnels := make(map[string]*Tunnel, len(c.tunnels))
+	for tunnelName, tunnel := range c.tunnels {
+		tunnels[tunnelName] = tunnel
+	}
+
+	return &backgroundState{
+		tunnels:          tunnels,
+		execResult:       c.execResult,
+		httpState:        c.httpState.copy(),
+		processedObjects: c.processedObjects,
+		offline:          c.offline,
+	}
+}
+
+// restoreBackgroundState restores the given state, and closes the tunnels opened since it has been saved.
+// Note that the tunnels of the background closed by a scenario are not re-opened.
+func (c *Context) restoreBackgroundState(b *backgroundState) {
+	for tunnelName, tunnel := range c.tunnels {
+		if b.tunnels[tunnelName] != tunnel {
+			c.CloseTunnel(tunnelName)
+		}
+	}
+
+	c.execResult = b.execResult
+	c.httpState = b.httpState.copy()
+	c.processedObjects = b.processedObjects
+	c.offline = b.offline
+}
@@ -0,0 +1,89 @@
+package steps
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lsegal/gucumber/gherkin"
+)
+
+func TestFeatureHookEnds(t *testing.T) {
+	tests := []struct {
+		feature      string
+		filters      []string
+		expectedEnds []hookEnd
+	}{
+		{
+			feature: `Feature: no background
+  Scenario: first
+    Given a step
+  Scenario: second
+    Given a step
+`,
+			expectedEnds: []hookEnd{scenarioEnd, scenarioEnd, featureEnd},
+		},
+		{
+			feature: `Feature: with background
+  Background:
+    Given a step
+  Scenario: first
+    Given a step
+  Scenario: second
+    Given a step
+`,
+			expectedEnds: []hookEnd{backgroundEnd, scenarioEnd, scenarioEnd, featureEnd},
+		},
+		{
+			feature: `Feature: with an outline
+  Background:
+    Given a step
+  Scenario Outline: outline
+    Given a step <value>
+
+    Examples:
+      | value |
+      | 1     |
+      | 2     |
+`,
+			expectedEnds: []hookEnd{backgroundEnd, scenarioEnd, scenarioEnd, scenarioEnd, featureEnd},
+		},
+		{
+			feature: `Feature: filtered
+  Background:
+    Given a step
+  @run
+  Scenario: first
+    Given a step
+  Scenario: second
+    Given a step
+`,
+			filters:      []string{"@run"},
+			expectedEnds: []hookEnd{scenarioEnd, featureEnd},
+		},
+		{
+			feature: `Feature: not run
+  Scenario: first
+    Given a step
+`,
+			filters:      []string{"@run"},
+			expectedEnds: nil,
+		},
+	}
+
+	for _, test := range tests {
+		features, err := gherkin.Parse(test.feature)
+		if err != nil {
+			t.Errorf("Failed to parse feature %q: %v", test.feature, err)
+			continue
+		}
+		if len(features) != 1 {
+			t.Errorf("Expected 1 feature in %q, but got %d", test.feature, len(features))
+			continue
+		}
+
+		ends := featureHookEnds(&features[0], test.filters)
+		if !reflect.DeepEqual(test.expectedEnds, ends) {
+			t.Errorf("featureHookEnds(%q, %v): expected %v, but got %v", features[0].Title, test.filters, test.expectedEnds, ends)
+		}
+	}
+}
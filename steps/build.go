@@ -8,6 +8,7 @@ import (
 	buildapi "github.com/openshift/origin/pkg/build/api"
 
 	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/kubectl/resource"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -71,7 +72,12 @@ func init() {
 					fmt.Printf("Build logs '%v'\n", logs)
 				}
 
-				c.Fail("Build '%s' was not successful!", latestBuildName)
+				involvedObjects := []kapi.ObjectReference{{Kind: "Build", Name: latestBuildName}}
+				if build, err := c.GetBuild(latestBuildName); err == nil {
+					involvedObjects = append(involvedObjects, kapi.ObjectReference{Kind: "Pod", Name: buildapi.GetBuildPodName(build)})
+				}
+
+				c.FailWithEvents(involvedObjects, "Build '%s' was not successful!", latestBuildName)
 				return
 			}
 		})
@@ -99,6 +105,26 @@ func (c *Context) GetBuildConfig(bcName string) (*buildapi.BuildConfig, error) {
 	return bc, nil
 }
 
+// GetBuild gets the Build with the given name, or returns an error
+func (c *Context) GetBuild(buildName string) (*buildapi.Build, error) {
+	client, _, err := c.Clients()
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, err := c.Namespace()
+	if err != nil {
+		return nil, err
+	}
+
+	build, err := client.Builds(namespace).Get(buildName)
+	if err != nil {
+		return nil, err
+	}
+
+	return build, nil
+}
+
 // StartNewBuild starts a new build for the BuildConfig with the given name
 // and returns the newly created Build, or an error
 func (c *Context) StartNewBuild(bcName string) (*buildapi.Build, error) {
@@ -132,43 +158,33 @@ func (c *Context) StartNewBuild(bcName string) (*buildapi.Build, error) {
 //
 // It returns true if the build completed, or false if it failed (or was cancelled).
 func (c *Context) IsBuildComplete(buildName string, timeout time.Duration) (bool, error) {
-	client, _, err := c.Clients()
-	if err != nil {
-		return false, err
-	}
-
-	namespace, err := c.Namespace()
-	if err != nil {
-		return false, err
-	}
-
-	startTime := time.Now()
-
-	// TODO use Watch instead of manually polling
-	for time.Now().Sub(startTime) < timeout {
-		var build *buildapi.Build
-
-		err = c.ExecWithExponentialBackoff(func() (err error) {
-			build, err = client.Builds(namespace).Get(buildName)
-			return
-		})
-		if err != nil {
-			return false, err
+	var complete bool
+	_, err := c.WaitFor("build", buildName, func(info *resource.Info) (bool, error) {
+		if info.Object == nil {
+			return false, nil
+		}
+		build, ok := info.Object.(*buildapi.Build)
+		if !ok {
+			return false, fmt.Errorf("Expected a Build, but got %T", info.Object)
 		}
 
 		switch build.Status.Phase {
 		case buildapi.BuildPhaseNew, buildapi.BuildPhasePending, buildapi.BuildPhaseRunning:
-			time.Sleep(5 * time.Second)
+			return false, nil
 		case buildapi.BuildPhaseComplete:
+			complete = true
 			return true, nil
 		case buildapi.BuildPhaseFailed, buildapi.BuildPhaseError, buildapi.BuildPhaseCancelled:
-			return false, nil
+			return true, nil
 		default:
 			return false, fmt.Errorf("Unknown phase %v", build.Status.Phase)
 		}
-	}
+	}, timeout)
 
-	return false, nil
+	if err == ErrWaitTimeout {
+		return false, nil
+	}
+	return complete, err
 }
 
 func (c *Context) GetBuildLogs(buildName string) (string, error) {
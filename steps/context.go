@@ -2,12 +2,15 @@ package steps
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/openshift/origin/pkg/client"
 	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
 
+	kapi "k8s.io/kubernetes/pkg/api"
 	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/runtime"
 
 	"github.com/cenkalti/backoff"
 	"github.com/lsegal/gucumber"
@@ -22,8 +25,18 @@ type Context struct {
 
 	factory   *clientcmd.Factory
 	namespace string
+	offline   bool
 
-	tunnels map[string]Tunnel
+	tunnels map[string]*Tunnel
+
+	execResult *ExecResult
+
+	httpState httpState
+
+	processedObjects []runtime.Object
+
+	hookEnds   []hookEnd
+	background *backgroundState
 
 	backOff *backoff.ExponentialBackOff
 }
@@ -36,9 +49,10 @@ func NewContext(gc *gucumber.Context) *Context {
 
 	c := &Context{
 		Context: gc,
-		tunnels: make(map[string]Tunnel),
+		tunnels: make(map[string]*Tunnel),
 		backOff: b,
 	}
+	c.resetHTTPState()
 
 	// register all steps with this context
 	for _, registerer := range stepsRegisterers {
@@ -105,10 +119,7 @@ func (c *Context) ClientConfig() (*kclient.Config, error) {
 // GetTunnel returns the tunnel with the given name
 // or nil if no tunnel exists with this name
 func (c *Context) GetTunnel(tunnelName string) *Tunnel {
-	if tunnel, found := c.tunnels[tunnelName]; found {
-		return &tunnel
-	}
-	return nil
+	return c.tunnels[tunnelName]
 }
 
 // Fail fails the current step
@@ -119,6 +130,33 @@ func (c *Context) Fail(msgAndArgs ...interface{}) bool {
 	return assert.Fail(c.T, "", msgAndArgs...)
 }
 
+// FailWithEvents fails the current step, like Fail,
+// and appends the events related to the given involved objects to the failure message
+// so that the real cause of the failure (image pull, scheduling, quota, ...) is reported
+func (c *Context) FailWithEvents(involvedObjects []kapi.ObjectReference, msgAndArgs ...interface{}) bool {
+	var msg string
+	switch len(msgAndArgs) {
+	case 0:
+	case 1:
+		msg = fmt.Sprint(msgAndArgs[0])
+	default:
+		msg = fmt.Sprintf(fmt.Sprint(msgAndArgs[0]), msgAndArgs[1:]...)
+	}
+
+	for _, involvedObject := range involvedObjects {
+		events, err := c.GetEventsFor(involvedObject.Kind, involvedObject.Name)
+		if err != nil {
+			msg += fmt.Sprintf("\nFailed to get events for %s '%s': %v", involvedObject.Kind, involvedObject.Name, err)
+			continue
+		}
+		if len(events.Items) > 0 {
+			msg += fmt.Sprintf("\nEvents for %s '%s':\n%s", involvedObject.Kind, involvedObject.Name, formatEvents(events.Items))
+		}
+	}
+
+	return c.Fail(msg)
+}
+
 // ExecWithExponentialBackoff executes an operation with an exponential backoff retry
 // and returns the operation's error
 func (c *Context) ExecWithExponentialBackoff(op backoff.Operation) error {
@@ -0,0 +1,158 @@
+package steps
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// registers all copy related steps
+func init() {
+	RegisterSteps(func(c *Context) {
+
+		c.When(`^I copy the file "(.+?)" to "(.+?)" in a pod of "(.+?)"$`, func(fileName string, remotePath string, dcName string) {
+			expandedFileName := os.ExpandEnv(fileName)
+			if expandedFileName == "" {
+				c.Fail("File name '%s' (expanded to '%s') is empty !", fileName, expandedFileName)
+				return
+			}
+			if _, err := os.Stat(expandedFileName); err != nil {
+				c.Fail("File '%s' (expanded to '%s') does not exists: %v", fileName, expandedFileName, err)
+				return
+			}
+
+			pod, err := c.GetRunningPodOfLatestDeployment(dcName)
+			if err != nil {
+				c.Fail("Failed to find a running pod of the latest deployment of '%s': %v", dcName, err)
+				return
+			}
+
+			if err = c.CopyFileToPod(expandedFileName, pod.Name, "", remotePath); err != nil {
+				c.Fail("Failed to copy the file '%s' to '%s' in the pod '%s': %v", expandedFileName, remotePath, pod.Name, err)
+				return
+			}
+		})
+
+		c.When(`^I copy "(.+?)" from a pod of "(.+?)" to "(.+?)"$`, func(remotePath string, dcName string, fileName string) {
+			expandedFileName := os.ExpandEnv(fileName)
+			if expandedFileName == "" {
+				c.Fail("File name '%s' (expanded to '%s') is empty !", fileName, expandedFileName)
+				return
+			}
+
+			pod, err := c.GetRunningPodOfLatestDeployment(dcName)
+			if err != nil {
+				c.Fail("Failed to find a running pod of the latest deployment of '%s': %v", dcName, err)
+				return
+			}
+
+			if err = c.CopyFileFromPod(pod.Name, "", remotePath, expandedFileName); err != nil {
+				c.Fail("Failed to copy '%s' from the pod '%s' to '%s': %v", remotePath, pod.Name, expandedFileName, err)
+				return
+			}
+		})
+
+	})
+}
+
+// CopyFileToPod copies the given local file to the given remote path in the given pod.
+// The remote path is the path of the file, not the path of its directory.
+// If the container name is empty, the first container of the pod is used.
+//
+// The file is streamed as a tar archive to the tar command of the container,
+// so the container image needs to provide tar.
+// It returns an error if the copy failed.
+func (c *Context) CopyFileToPod(fileName string, podName string, containerName string, remotePath string) error {
+	if strings.HasSuffix(remotePath, "/") {
+		return fmt.Errorf("The remote path '%s' should be the path of the file, not of a directory", remotePath)
+	}
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("'%s' is a directory, only files can be copied", fileName)
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(writer)
+		err := tw.WriteHeader(&tar.Header{
+			Name:    path.Base(remotePath),
+			Mode:    int64(info.Mode().Perm()),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+		if err == nil {
+			_, err = io.Copy(tw, file)
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+
+	command := []string{"tar", "-xmf", "-", "-C", path.Dir(remotePath)}
+	err = c.ExecInPod(podName, containerName, command, reader, nil, nil)
+
+	// unblock the writing goroutine if the command did not read the whole archive (for example if it failed to start)
+	reader.Close()
+	return err
+}
+
+// CopyFileFromPod copies the file at the given remote path in the given pod to the given local file.
+// If the container name is empty, the first container of the pod is used.
+// The parent directories of the local file are created if needed.
+//
+// The file is streamed as a tar archive from the tar command of the container,
+// so the container image needs to provide tar.
+// It returns an error if the copy failed.
+func (c *Context) CopyFileFromPod(podName string, containerName string, remotePath string, fileName string) error {
+	reader, writer := io.Pipe()
+	go func() {
+		command := []string{"tar", "-cf", "-", "-C", path.Dir(remotePath), path.Base(remotePath)}
+		writer.CloseWithError(c.ExecInPod(podName, containerName, command, nil, writer, nil))
+	}()
+	defer reader.Close()
+
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("Could not find the file '%s' in the pod '%s'", remotePath, podName)
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		if err = os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			return err
+		}
+
+		// the file should at least be readable and writable by the current user
+		file, err := os.OpenFile(fileName, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode).Perm()|0600)
+		if err != nil {
+			return err
+		}
+
+		if _, err = io.Copy(file, tr); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+}
@@ -3,13 +3,18 @@ package steps
 import (
 	"fmt"
 	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	deployapi "github.com/openshift/origin/pkg/deploy/api"
 	deployutil "github.com/openshift/origin/pkg/deploy/util"
 
 	kapi "k8s.io/kubernetes/pkg/api"
+	kerrors "k8s.io/kubernetes/pkg/api/errors"
 	"k8s.io/kubernetes/pkg/fields"
+	"k8s.io/kubernetes/pkg/kubectl/resource"
 	"k8s.io/kubernetes/pkg/labels"
 
 	"github.com/stretchr/testify/assert"
@@ -87,43 +92,167 @@ func init() {
 			}
 
 			if !success {
-				logs, err := c.GetDeploymentLogs(latestDeploymentName)
+				logs, err := c.GetDeploymentLogs(dc.Name, dc.Status.LatestVersion)
 				if err != nil {
-					fmt.Printf("Failed to get deployment logs '%v'\n", err)
-				} else {
-					fmt.Printf("Deployment logs '%v'\n", logs)
+					logs = fmt.Sprintf("failed to get the deployment logs: %v", err)
 				}
-				c.Fail("Deployment '%s' was not successful!", latestDeploymentName)
+				description, err := c.DescribeDeployment(latestDeploymentName)
+				if err != nil {
+					c.FailWithEvents(c.deploymentInvolvedObjects(latestDeploymentName), "Deployment '%s' was not successful! (failed to describe it: %v)\nDeployment logs:\n%s", latestDeploymentName, err, logs)
+					return
+				}
+				c.FailWithEvents(c.deploymentInvolvedObjects(latestDeploymentName), "Deployment '%s' was not successful!\n%s\nDeployment logs:\n%s", latestDeploymentName, description, logs)
 				return
 			}
 		})
 
 		c.When(`^I have a successful deployment of "(.+?)"$`, func(dcName string) {
-			rcList, err := c.GetReplicationControllers(deployutil.ConfigSelector(dcName))
+			dc, err := c.GetDeploymentConfig(dcName)
 			if err != nil {
 				c.Fail("Failed to get Deployment Config '%s': %v", dcName, err)
 				return
 			}
 
-			var successfulDeployment bool
-			for _, rc := range rcList.Items {
-				if status, ok := rc.Annotations[deployapi.DeploymentStatusAnnotation]; ok {
-					switch status {
-					case string(deployapi.DeploymentStatusComplete):
-						successfulDeployment = true
-					default:
-					}
+			if dc.Status.LatestVersion == 0 {
+				c.Fail("The Deployment Config '%s' has no deployment", dcName)
+				return
+			}
+
+			latestDeploymentName := deployutil.LatestDeploymentNameForConfig(dc)
+			description, err := c.DescribeDeployment(latestDeploymentName)
+			if err != nil {
+				c.Fail("Failed to describe the deployment '%s': %v", latestDeploymentName, err)
+				return
+			}
+
+			if description.Status != deployapi.DeploymentStatusComplete {
+				logs, err := c.GetDeploymentLogs(dcName, dc.Status.LatestVersion)
+				if err != nil {
+					logs = fmt.Sprintf("failed to get the deployment logs: %v", err)
 				}
+				c.FailWithEvents(c.deploymentInvolvedObjects(latestDeploymentName), "The latest deployment of '%s' is not successful:\n%s\nDeployment logs:\n%s", dcName, description, logs)
+				return
+			}
+		})
+
+		c.When(`^I have at least one successful deployment of "(.+?)"$`, func(dcName string) {
+			descriptions, err := c.DescribeDeployments(dcName)
+			if err != nil {
+				c.Fail("Failed to describe the deployments of '%s': %v", dcName, err)
+				return
+			}
+
+			for _, description := range descriptions {
+				if description.Status == deployapi.DeploymentStatusComplete {
+					return
+				}
+			}
+
+			details := []string{}
+			for _, description := range descriptions {
+				details = append(details, description.String())
+			}
+			c.Fail("No successful deployment for '%s' (%d deployments):\n%s", dcName, len(descriptions), strings.Join(details, "\n"))
+		})
+
+		c.When(`^I deploy the latest version of "(.+?)"$`, func(dcName string) {
+			if _, err := c.DeployLatest(dcName); err != nil {
+				c.Fail("Failed to deploy the latest version of '%s': %v", dcName, err)
+				return
+			}
+		})
+
+		c.When(`^I rollback "(.+?)" to version (\d+)$`, func(dcName string, version int) {
+			if _, err := c.RollbackDeploymentConfig(dcName, version); err != nil {
+				c.Fail("Failed to rollback '%s' to version %d: %v", dcName, version, err)
+				return
+			}
+		})
+
+		c.Then(`^the deploymentconfig "(.+?)" should be running version (\d+)$`, func(dcName string, expectedVersion int) {
+			activeVersion, running, err := c.IsRunningDeploymentVersion(dcName, expectedVersion, defaultWaitTimeout)
+			if err != nil {
+				c.Fail("Failed to check the version of the Deployment Config '%s': %v", dcName, err)
+				return
+			}
+
+			if !running {
+				c.Fail("The Deployment Config '%s' is running version %d, which does not have the pod template of the expected version %d", dcName, activeVersion, expectedVersion)
+				return
+			}
+		})
+
+		c.Then(`^the deploymentconfig "(.+?)" should have a "(.+?)" deployment strategy$`, func(dcName string, expectedStrategyType string) {
+			dc, err := c.GetDeploymentConfig(dcName)
+			if err != nil {
+				c.Fail("Failed to get Deployment Config '%s': %v", dcName, err)
+				return
 			}
 
-			if !successfulDeployment {
-				logs, err := c.GetDeploymentLogs(dcName)
+			assert.Equal(c.T, expectedStrategyType, string(dc.Spec.Strategy.Type), "The Deployment Config '%s' has a '%s' deployment strategy, but expected strategy is '%s'", dcName, dc.Spec.Strategy.Type, expectedStrategyType)
+		})
+
+		c.Then(`^the deploymentconfig "(.+?)" should have the deployment strategy parameter "(.+?)" equal to "(.+?)"$`, func(dcName string, parameterName string, expectedValue string) {
+			dc, err := c.GetDeploymentConfig(dcName)
+			if err != nil {
+				c.Fail("Failed to get Deployment Config '%s': %v", dcName, err)
+				return
+			}
+
+			value, err := deploymentStrategyParameter(dc.Spec.Strategy, parameterName)
+			if err != nil {
+				c.Fail("Failed to get the deployment strategy parameter '%s' of the Deployment Config '%s': %v", parameterName, dcName, err)
+				return
+			}
+
+			assert.Equal(c.T, expectedValue, value, "The deployment strategy parameter '%s' of the Deployment Config '%s' is '%s', but expected value is '%s'", parameterName, dcName, value, expectedValue)
+		})
+
+		c.Then(`^the deploymentconfig "(.+?)" should have a "(pre|post)" hook with failure policy "(.+?)"$`, func(dcName string, hookType string, expectedFailurePolicy string) {
+			dc, err := c.GetDeploymentConfig(dcName)
+			if err != nil {
+				c.Fail("Failed to get Deployment Config '%s': %v", dcName, err)
+				return
+			}
+
+			hook := deploymentStrategyHook(dc.Spec.Strategy, hookType)
+			if hook == nil {
+				c.Fail("The Deployment Config '%s' has no '%s' hook for its '%s' deployment strategy", dcName, hookType, dc.Spec.Strategy.Type)
+				return
+			}
+
+			assert.Equal(c.T, expectedFailurePolicy, string(hook.FailurePolicy), "The '%s' hook of the Deployment Config '%s' has the failure policy '%s', but expected failure policy is '%s'", hookType, dcName, hook.FailurePolicy, expectedFailurePolicy)
+		})
+
+		c.Then(`^the hook pods of the latest deployment of "(.+?)" should have succeeded$`, func(dcName string) {
+			dc, err := c.GetDeploymentConfig(dcName)
+			if err != nil {
+				c.Fail("Failed to get Deployment Config '%s': %v", dcName, err)
+				return
+			}
+
+			latestDeploymentName := deployutil.LatestDeploymentNameForConfig(dc)
+			hookPods, err := c.GetDeploymentHookPods(latestDeploymentName)
+			if err != nil {
+				c.Fail("Failed to get the hook pods of the deployment '%s': %v", latestDeploymentName, err)
+				return
+			}
+
+			if len(hookPods) == 0 {
+				c.Fail("The deployment '%s' has no hook pods", latestDeploymentName)
+				return
+			}
+
+			for _, pod := range hookPods {
+				if pod.Status.Phase == kapi.PodSucceeded {
+					continue
+				}
+
+				logs, err := c.GetPodLogs(pod.Name, &kapi.PodLogOptions{})
 				if err != nil {
-					fmt.Printf("Failed to get deployment logs '%v'\n", err)
-				} else {
-					fmt.Printf("Deployment logs '%v'\n", logs)
+					logs = fmt.Sprintf("failed to get logs: %v", err)
 				}
-				c.Fail("No successful deployment for '%s'", dcName)
+				c.Fail("The hook pod '%s' of the deployment '%s' is in phase '%s' instead of '%s'. Hook pod logs:\n%s", pod.Name, latestDeploymentName, pod.Status.Phase, kapi.PodSucceeded, logs)
 				return
 			}
 		})
@@ -202,6 +331,26 @@ func (c *Context) GetReplicationControllers(labelSelector labels.Selector) (*kap
 	return rcList, nil
 }
 
+// GetReplicationController gets the ReplicationController with the given name, or returns an error
+func (c *Context) GetReplicationController(rcName string) (*kapi.ReplicationController, error) {
+	_, kclient, err := c.Clients()
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, err := c.Namespace()
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := kclient.ReplicationControllers(namespace).Get(rcName)
+	if err != nil {
+		return nil, err
+	}
+
+	return rc, nil
+}
+
 // DeleteDeploymentConfig deletes the DeploymentConfig with the given name, or returns an error
 func (c *Context) DeleteDeploymentConfig(dcName string) error {
 	client, _, err := c.Clients()
@@ -221,54 +370,293 @@ func (c *Context) DeleteDeploymentConfig(dcName string) error {
 	return nil
 }
 
-// IsDeploymentComplete checks if the deployment with the given name is complete.
+// DeployLatest starts a new deployment for the DeploymentConfig with the given name,
+// by bumping its latest version.
 //
-// If the deployment is still running, it will wait for the given timeout duration.
+// It returns an error if a deployment is already in progress.
+// It returns the updated DeploymentConfig, or an error.
+func (c *Context) DeployLatest(dcName string) (*deployapi.DeploymentConfig, error) {
+	client, kclient, err := c.Clients()
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, err := c.Namespace()
+	if err != nil {
+		return nil, err
+	}
+
+	dc, err := client.DeploymentConfigs(namespace).Get(dcName)
+	if err != nil {
+		return nil, err
+	}
+
+	latestDeploymentName := deployutil.LatestDeploymentNameForConfig(dc)
+	rc, err := kclient.ReplicationControllers(namespace).Get(latestDeploymentName)
+	switch {
+	case err == nil:
+		switch status := deployutil.DeploymentStatusFor(rc); status {
+		case deployapi.DeploymentStatusComplete, deployapi.DeploymentStatusFailed:
+		default:
+			return nil, fmt.Errorf("Deployment '%s' is already in progress (%s)", latestDeploymentName, status)
+		}
+	case !kerrors.IsNotFound(err):
+		return nil, err
+	}
+
+	dc.Status.LatestVersion++
+	dc, err = client.DeploymentConfigs(namespace).Update(dc)
+	if err != nil {
+		return nil, err
+	}
+
+	return dc, nil
+}
+
+// RollbackDeploymentConfig rolls back the DeploymentConfig with the given name
+// to the pod template of the deployment with the given version.
 //
-// It returns true if the deployment completed, or false if it failed.
-func (c *Context) IsDeploymentComplete(deploymentName string, timeout time.Duration) (bool, error) {
-	_, kclient, err := c.Clients()
+// The triggers, strategy and scaling settings are left unchanged,
+// and a new deployment will be started with the rolled back config.
+//
+// It returns the updated DeploymentConfig, or an error.
+func (c *Context) RollbackDeploymentConfig(dcName string, version int) (*deployapi.DeploymentConfig, error) {
+	client, _, err := c.Clients()
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
 	namespace, err := c.Namespace()
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	startTime := time.Now()
+	rollback := &deployapi.DeploymentConfigRollback{
+		Spec: deployapi.DeploymentConfigRollbackSpec{
+			From: kapi.ObjectReference{
+				Name: deployutil.DeploymentNameForConfigVersion(dcName, version),
+			},
+			IncludeTemplate: true,
+		},
+	}
 
-	// TODO use Watch instead of manually polling
-	for time.Now().Sub(startTime) < timeout {
-		var rc *kapi.ReplicationController
+	dc, err := client.DeploymentConfigs(namespace).Rollback(rollback)
+	if err != nil {
+		return nil, err
+	}
 
-		err = c.ExecWithExponentialBackoff(func() (err error) {
-			rc, err = kclient.ReplicationControllers(namespace).Get(deploymentName)
-			return
-		})
+	dc, err = client.DeploymentConfigs(namespace).Update(dc)
+	if err != nil {
+		return nil, err
+	}
+
+	return dc, nil
+}
+
+// IsRunningDeploymentVersion checks if the DeploymentConfig with the given name
+// is running the pod template of the deployment with the given version.
+//
+// A rollback to a version starts a new deployment (with a new version number) using the pod template
+// of the rolled back version, so the active deployment is either the given version itself,
+// or a later deployment with the same pod template.
+//
+// If the latest deployment is still running, it will wait for the given timeout duration.
+//
+// It returns the version of the active deployment, and true if it runs the given version.
+func (c *Context) IsRunningDeploymentVersion(dcName string, version int, timeout time.Duration) (int, bool, error) {
+	dc, err := c.GetDeploymentConfig(dcName)
+	if err != nil {
+		return 0, false, err
+	}
+
+	latestDeploymentName := deployutil.LatestDeploymentNameForConfig(dc)
+	if _, err = c.IsDeploymentComplete(latestDeploymentName, timeout); err != nil {
+		return 0, false, err
+	}
+
+	rcList, err := c.GetReplicationControllers(deployutil.ConfigSelector(dcName))
+	if err != nil {
+		return 0, false, err
+	}
+
+	active := deployutil.ActiveDeployment(dc, rcList)
+	if active == nil {
+		return 0, false, fmt.Errorf("The Deployment Config '%s' has no active deployment", dcName)
+	}
+
+	activeVersion := deployutil.DeploymentVersionFor(active)
+	if activeVersion == version {
+		return activeVersion, true, nil
+	}
+
+	expected, err := c.GetReplicationController(deployutil.DeploymentNameForConfigVersion(dcName, version))
+	if err != nil {
+		return activeVersion, false, err
+	}
+
+	if active.Spec.Template == nil || expected.Spec.Template == nil {
+		return activeVersion, false, nil
+	}
+	return activeVersion, kapi.Semantic.DeepEqual(active.Spec.Template.Spec, expected.Spec.Template.Spec), nil
+}
+
+// DeploymentDescription contains diagnostics about a deployment:
+// its status, the reason of this status (if any), and the events of its deployer pod
+type DeploymentDescription struct {
+	Name              string
+	Version           int
+	Status            deployapi.DeploymentStatus
+	Reason            string
+	DeployerPodName   string
+	DeployerPodEvents []kapi.Event
+}
+
+// String returns a human-readable representation of the deployment description
+func (d *DeploymentDescription) String() string {
+	s := fmt.Sprintf("Deployment '%s' (#%d): %s", d.Name, d.Version, d.Status)
+	if len(d.Reason) > 0 {
+		s += fmt.Sprintf(" (reason: %s)", d.Reason)
+	}
+	if len(d.DeployerPodEvents) > 0 {
+		s += fmt.Sprintf("\nEvents for deployer pod '%s':\n%s", d.DeployerPodName, formatEvents(d.DeployerPodEvents))
+	}
+	return s
+}
+
+// DescribeDeployment returns diagnostics about the deployment with the given name
+// (for example "hello-1"), or an error
+func (c *Context) DescribeDeployment(deploymentName string) (*DeploymentDescription, error) {
+	rc, err := c.GetReplicationController(deploymentName)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.describeDeployment(rc)
+}
+
+// DescribeDeployments returns diagnostics about all the deployments
+// of the DeploymentConfig with the given name, starting with the latest deployment
+func (c *Context) DescribeDeployments(dcName string) ([]*DeploymentDescription, error) {
+	rcList, err := c.GetReplicationControllers(deployutil.ConfigSelector(dcName))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(deployutil.ByLatestVersionDesc(rcList.Items))
+
+	descriptions := []*DeploymentDescription{}
+	for i := range rcList.Items {
+		description, err := c.describeDeployment(&rcList.Items[i])
 		if err != nil {
-			return false, err
+			return nil, err
 		}
+		descriptions = append(descriptions, description)
+	}
 
-		if status, ok := rc.Annotations[deployapi.DeploymentStatusAnnotation]; ok {
-			switch status {
-			case string(deployapi.DeploymentStatusNew), string(deployapi.DeploymentStatusPending), string(deployapi.DeploymentStatusRunning):
-				time.Sleep(5 * time.Second)
-			case string(deployapi.DeploymentStatusComplete):
-				return true, nil
-			case string(deployapi.DeploymentStatusFailed):
-				return false, nil
-			default:
-				return false, fmt.Errorf("Unknown status %v", status)
-			}
+	return descriptions, nil
+}
+
+// describeDeployment returns diagnostics about the given deployment, or an error
+func (c *Context) describeDeployment(rc *kapi.ReplicationController) (*DeploymentDescription, error) {
+	description := &DeploymentDescription{
+		Name:            rc.Name,
+		Version:         deployutil.DeploymentVersionFor(rc),
+		Status:          deployutil.DeploymentStatusFor(rc),
+		Reason:          deployutil.DeploymentStatusReasonFor(rc),
+		DeployerPodName: deployutil.DeployerPodNameFor(rc),
+	}
+	if len(description.DeployerPodName) == 0 {
+		description.DeployerPodName = deployutil.DeployerPodNameForDeployment(rc.Name)
+	}
+
+	// the events are only a diagnostic, so failing to get them should not hide the status of the deployment
+	events, err := c.GetEventsFor("Pod", description.DeployerPodName)
+	if err != nil {
+		fmt.Printf("Failed to get events for deployer pod '%s': %v\n", description.DeployerPodName, err)
+	} else {
+		description.DeployerPodEvents = events.Items
+	}
+
+	return description, nil
+}
+
+// deploymentInvolvedObjects returns references to the objects involved in the deployment
+// with the given name: the deployment itself, and the pods created for the deployment.
+// The deployer pod is not included, because its events are already part of the DeploymentDescription.
+func (c *Context) deploymentInvolvedObjects(deploymentName string) []kapi.ObjectReference {
+	involvedObjects := []kapi.ObjectReference{{Kind: "ReplicationController", Name: deploymentName}}
+
+	deploymentSelector := labels.Set{deployapi.DeploymentLabel: deploymentName}.AsSelector()
+	if pods, err := c.GetPods(deploymentSelector); err == nil {
+		for _, pod := range pods.Items {
+			involvedObjects = append(involvedObjects, kapi.ObjectReference{Kind: "Pod", Name: pod.Name})
 		}
 	}
 
-	return false, nil
+	return involvedObjects
 }
 
-func (c *Context) GetDeploymentLogs(name string) (string, error) {
+// GetDeploymentHookPods gets the lifecycle hook pods of the deployment with the given name
+// (the deployer pod itself is not included), or returns an error
+func (c *Context) GetDeploymentHookPods(deploymentName string) ([]kapi.Pod, error) {
+	pods, err := c.GetPods(deployutil.DeployerPodSelector(deploymentName))
+	if err != nil {
+		return nil, err
+	}
+
+	deployerPodName := deployutil.DeployerPodNameForDeployment(deploymentName)
+	hookPods := []kapi.Pod{}
+	for _, pod := range pods.Items {
+		if pod.Name != deployerPodName {
+			hookPods = append(hookPods, pod)
+		}
+	}
+
+	return hookPods, nil
+}
+
+// IsDeploymentComplete checks if the deployment with the given name is complete.
+//
+// If the deployment is still running, it will wait for the given timeout duration.
+//
+// It returns true if the deployment completed, or false if it failed.
+func (c *Context) IsDeploymentComplete(deploymentName string, timeout time.Duration) (bool, error) {
+	var complete bool
+	_, err := c.WaitFor("replicationcontroller", deploymentName, func(info *resource.Info) (bool, error) {
+		if info.Object == nil {
+			return false, nil
+		}
+		rc, ok := info.Object.(*kapi.ReplicationController)
+		if !ok {
+			return false, fmt.Errorf("Expected a ReplicationController, but got %T", info.Object)
+		}
+
+		status, ok := rc.Annotations[deployapi.DeploymentStatusAnnotation]
+		if !ok {
+			return false, nil
+		}
+		switch status {
+		case string(deployapi.DeploymentStatusNew), string(deployapi.DeploymentStatusPending), string(deployapi.DeploymentStatusRunning):
+			return false, nil
+		case string(deployapi.DeploymentStatusComplete):
+			complete = true
+			return true, nil
+		case string(deployapi.DeploymentStatusFailed):
+			return true, nil
+		default:
+			return false, fmt.Errorf("Unknown status %v", status)
+		}
+	}, timeout)
+
+	if err == ErrWaitTimeout {
+		return false, nil
+	}
+	return complete, err
+}
+
+// GetDeploymentLogs returns the logs of the deployment with the given version
+// of the deployment config with the given name
+func (c *Context) GetDeploymentLogs(dcName string, version int) (string, error) {
 	client, _, err := c.Clients()
 	if err != nil {
 		return "", err
@@ -279,7 +667,8 @@ func (c *Context) GetDeploymentLogs(name string) (string, error) {
 		return "", err
 	}
 
-	readCloser, err := client.DeploymentLogs(namespace).Get(name, deployapi.DeploymentLogOptions{}).Stream()
+	deploymentVersion := int64(version)
+	readCloser, err := client.DeploymentLogs(namespace).Get(dcName, deployapi.DeploymentLogOptions{Version: &deploymentVersion}).Stream()
 	if err != nil {
 		return "", err
 	}
@@ -293,3 +682,75 @@ func (c *Context) GetDeploymentLogs(name string) (string, error) {
 
 	return string(bytes), nil
 }
+
+// deploymentStrategyParameter returns the value of the parameter with the given name
+// for the given deployment strategy, or an error if the strategy has no such parameter
+func deploymentStrategyParameter(strategy deployapi.DeploymentStrategy, name string) (string, error) {
+	switch strategy.Type {
+	case deployapi.DeploymentStrategyTypeRolling:
+		params := strategy.RollingParams
+		if params == nil {
+			return "", fmt.Errorf("No parameters for the '%s' deployment strategy", strategy.Type)
+		}
+		switch name {
+		case "updatePeriodSeconds":
+			return formatInt64Pointer(params.UpdatePeriodSeconds), nil
+		case "intervalSeconds":
+			return formatInt64Pointer(params.IntervalSeconds), nil
+		case "timeoutSeconds":
+			return formatInt64Pointer(params.TimeoutSeconds), nil
+		case "maxUnavailable":
+			return params.MaxUnavailable.String(), nil
+		case "maxSurge":
+			return params.MaxSurge.String(), nil
+		case "updatePercent":
+			if params.UpdatePercent == nil {
+				return "", nil
+			}
+			return strconv.Itoa(*params.UpdatePercent), nil
+		}
+	case deployapi.DeploymentStrategyTypeCustom:
+		params := strategy.CustomParams
+		if params == nil {
+			return "", fmt.Errorf("No parameters for the '%s' deployment strategy", strategy.Type)
+		}
+		switch name {
+		case "image":
+			return params.Image, nil
+		case "command":
+			return strings.Join(params.Command, " "), nil
+		}
+	}
+
+	return "", fmt.Errorf("Unknown parameter '%s' for the '%s' deployment strategy", name, strategy.Type)
+}
+
+// deploymentStrategyHook returns the lifecycle hook of the given type (pre or post)
+// for the given deployment strategy, or nil if there is no such hook
+func deploymentStrategyHook(strategy deployapi.DeploymentStrategy, hookType string) *deployapi.LifecycleHook {
+	switch {
+	case strategy.RollingParams != nil:
+		switch hookType {
+		case "pre":
+			return strategy.RollingParams.Pre
+		case "post":
+			return strategy.RollingParams.Post
+		}
+	case strategy.RecreateParams != nil:
+		switch hookType {
+		case "pre":
+			return strategy.RecreateParams.Pre
+		case "post":
+			return strategy.RecreateParams.Post
+		}
+	}
+	return nil
+}
+
+// formatInt64Pointer formats the given int64 value, or returns an empty string if nil
+func formatInt64Pointer(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
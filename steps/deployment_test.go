@@ -0,0 +1,66 @@
+package steps
+
+import (
+	"testing"
+
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+
+	kutil "k8s.io/kubernetes/pkg/util"
+)
+
+func TestDeploymentStrategyParameter(t *testing.T) {
+	updatePeriod := int64(1)
+	updatePercent := -20
+	rolling := deployapi.DeploymentStrategy{
+		Type: deployapi.DeploymentStrategyTypeRolling,
+		RollingParams: &deployapi.RollingDeploymentStrategyParams{
+			UpdatePeriodSeconds: &updatePeriod,
+			MaxUnavailable:      kutil.NewIntOrStringFromString("25%"),
+			MaxSurge:            kutil.NewIntOrStringFromInt(2),
+			UpdatePercent:       &updatePercent,
+		},
+	}
+	custom := deployapi.DeploymentStrategy{
+		Type: deployapi.DeploymentStrategyTypeCustom,
+		CustomParams: &deployapi.CustomDeploymentStrategyParams{
+			Image:   "deployer:latest",
+			Command: []string{"/bin/deploy", "--verbose"},
+		},
+	}
+
+	tests := []struct {
+		strategy      deployapi.DeploymentStrategy
+		name          string
+		expectedValue string
+		expectedError bool
+	}{
+		{rolling, "updatePeriodSeconds", "1", false},
+		{rolling, "intervalSeconds", "", false},
+		{rolling, "maxUnavailable", "25%", false},
+		{rolling, "maxSurge", "2", false},
+		{rolling, "updatePercent", "-20", false},
+		{rolling, "image", "", true},
+		{deployapi.DeploymentStrategy{Type: deployapi.DeploymentStrategyTypeRolling}, "maxSurge", "", true},
+		{custom, "image", "deployer:latest", false},
+		{custom, "command", "/bin/deploy --verbose", false},
+		{custom, "maxSurge", "", true},
+		{deployapi.DeploymentStrategy{Type: deployapi.DeploymentStrategyTypeRecreate}, "maxSurge", "", true},
+	}
+
+	for _, test := range tests {
+		value, err := deploymentStrategyParameter(test.strategy, test.name)
+		if test.expectedError {
+			if err == nil {
+				t.Errorf("deploymentStrategyParameter(%s, %s): expected an error, but got '%s'", test.strategy.Type, test.name, value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("deploymentStrategyParameter(%s, %s): unexpected error: %v", test.strategy.Type, test.name, err)
+			continue
+		}
+		if value != test.expectedValue {
+			t.Errorf("deploymentStrategyParameter(%s, %s): expected '%s', but got '%s'", test.strategy.Type, test.name, test.expectedValue, value)
+		}
+	}
+}
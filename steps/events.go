@@ -0,0 +1,110 @@
+package steps
+
+import (
+	"fmt"
+	"strings"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/labels"
+)
+
+// warningEventReasons contains the reasons of the events that are considered as warnings.
+// Events have no type in this API version, so the reason is used to classify them.
+var warningEventReasons = map[string]bool{
+	"failed":                  true,
+	"failedscheduling":        true,
+	"failedsync":              true,
+	"failedmount":             true,
+	"failedvalidation":        true,
+	"failedcreate":            true,
+	"faileddelete":            true,
+	"failedrescale":           true,
+	"backoff":                 true,
+	"unhealthy":               true,
+	"hostportconflict":        true,
+	"nodeselectormismatching": true,
+	"outofdisk":               true,
+	"insufficientfreecpu":     true,
+	"insufficientfreememory":  true,
+}
+
+// eventsInvolvedObjectKinds maps the kinds used in the steps to the API kinds
+var eventsInvolvedObjectKinds = map[string]string{
+	"pod":                   "Pod",
+	"build":                 "Build",
+	"deploymentconfig":      "DeploymentConfig",
+	"replicationcontroller": "ReplicationController",
+	"service":               "Service",
+	"node":                  "Node",
+}
+
+// registers all events related steps
+func init() {
+	RegisterSteps(func(c *Context) {
+
+		c.Then(`^there should be no "(.+?)" events for (pod|build|deploymentconfig|replicationcontroller|service|node) "(.+?)"$`, func(eventType string, kind string, name string) {
+			apiKind := eventsInvolvedObjectKinds[kind]
+			events, err := c.GetEventsFor(apiKind, name)
+			if err != nil {
+				c.Fail("Failed to get events for %s '%s': %v", kind, name, err)
+				return
+			}
+
+			matchingEvents := filterEvents(events.Items, eventType)
+			if len(matchingEvents) > 0 {
+				c.Fail("Found %d '%s' events for %s '%s':\n%s", len(matchingEvents), eventType, kind, name, formatEvents(matchingEvents))
+				return
+			}
+		})
+
+	})
+}
+
+// GetEventsFor gets the events related to the object with the given kind and name
+// in the current namespace, or returns an error
+func (c *Context) GetEventsFor(kind string, name string) (*kapi.EventList, error) {
+	_, kclient, err := c.Clients()
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, err := c.Namespace()
+	if err != nil {
+		return nil, err
+	}
+
+	events := kclient.Events(namespace)
+	fieldSelector := events.GetFieldSelector(&name, &namespace, &kind, nil)
+	eventList, err := events.List(labels.Everything(), fieldSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	return eventList, nil
+}
+
+// formatEvents formats the given events in a human-readable way,
+// one event per line
+func formatEvents(events []kapi.Event) string {
+	lines := []string{}
+	for _, event := range events {
+		lines = append(lines, fmt.Sprintf("%s\t%s/%s\t(x%d)\t%s", event.Reason, event.InvolvedObject.Kind, event.InvolvedObject.Name, event.Count, event.Message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// filterEvents returns the events matching the given type:
+// either "Warning" (based on the event reason), or a specific event reason
+func filterEvents(events []kapi.Event, eventType string) []kapi.Event {
+	matchingEvents := []kapi.Event{}
+	for _, event := range events {
+		reason := strings.ToLower(event.Reason)
+		switch {
+		case strings.EqualFold(eventType, "Warning") && warningEventReasons[reason]:
+			matchingEvents = append(matchingEvents, event)
+		case strings.EqualFold(eventType, event.Reason):
+			matchingEvents = append(matchingEvents, event)
+		}
+	}
+	return matchingEvents
+}
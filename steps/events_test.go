@@ -0,0 +1,34 @@
+package steps
+
+import (
+	"reflect"
+	"testing"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+)
+
+func TestFilterEvents(t *testing.T) {
+	scheduled := kapi.Event{Reason: "scheduled"}
+	pulled := kapi.Event{Reason: "Pulled"}
+	failedScheduling := kapi.Event{Reason: "FailedScheduling"}
+	backOff := kapi.Event{Reason: "BackOff"}
+	events := []kapi.Event{scheduled, pulled, failedScheduling, backOff}
+
+	tests := []struct {
+		eventType      string
+		expectedEvents []kapi.Event
+	}{
+		{"Warning", []kapi.Event{failedScheduling, backOff}},
+		{"warning", []kapi.Event{failedScheduling, backOff}},
+		{"Pulled", []kapi.Event{pulled}},
+		{"failedscheduling", []kapi.Event{failedScheduling}},
+		{"Killing", []kapi.Event{}},
+	}
+
+	for _, test := range tests {
+		matchingEvents := filterEvents(events, test.eventType)
+		if !reflect.DeepEqual(test.expectedEvents, matchingEvents) {
+			t.Errorf("filterEvents(%s): expected %v, but got %v", test.eventType, test.expectedEvents, matchingEvents)
+		}
+	}
+}
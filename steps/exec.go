@@ -0,0 +1,199 @@
+package steps
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/client/unversioned/remotecommand"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ExecResult is the result of a command executed in a pod
+type ExecResult struct {
+	PodName  string
+	Command  string
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// Output returns the combined standard output and standard error of the command
+func (r *ExecResult) Output() string {
+	return r.Stdout + r.Stderr
+}
+
+// registers all exec related steps
+func init() {
+	RegisterSteps(func(c *Context) {
+
+		c.When(`^I run "(.+?)" in a pod of the deploymentconfig "(.+?)"$`, func(command string, dcName string) {
+			pod, err := c.GetRunningPodOfLatestDeployment(dcName)
+			if err != nil {
+				c.Fail("Failed to find a running pod of the latest deployment of '%s': %v", dcName, err)
+				return
+			}
+
+			if _, err = c.RunCommandInPod(pod.Name, "", command); err != nil {
+				c.Fail("Failed to run '%s' in the pod '%s': %v", command, pod.Name, err)
+				return
+			}
+		})
+
+		c.When(`^I run "(.+?)" in the pod "(.+?)"$`, func(command string, podName string) {
+			if _, err := c.RunCommandInPod(podName, "", command); err != nil {
+				c.Fail("Failed to run '%s' in the pod '%s': %v", command, podName, err)
+				return
+			}
+		})
+
+		c.When(`^I run "(.+?)" in the container "(.+?)" of the pod "(.+?)"$`, func(command string, containerName string, podName string) {
+			if _, err := c.RunCommandInPod(podName, containerName, command); err != nil {
+				c.Fail("Failed to run '%s' in the container '%s' of the pod '%s': %v", command, containerName, podName, err)
+				return
+			}
+		})
+
+		c.Then(`^the command output should contain "(.+?)"$`, func(expectedText string) {
+			if c.execResult == nil {
+				c.Fail("No command has been run")
+				return
+			}
+
+			assert.Contains(c.T, c.execResult.Output(), expectedText, "The output of the command '%s' in the pod '%s' does not contain '%s'", c.execResult.Command, c.execResult.PodName, expectedText)
+		})
+
+		c.Then(`^the command output should not contain "(.+?)"$`, func(unexpectedText string) {
+			if c.execResult == nil {
+				c.Fail("No command has been run")
+				return
+			}
+
+			assert.NotContains(c.T, c.execResult.Output(), unexpectedText, "The output of the command '%s' in the pod '%s' contains '%s'", c.execResult.Command, c.execResult.PodName, unexpectedText)
+		})
+
+		c.Then(`^the (?:command )?exit code should be (\d+)$`, func(expectedExitCode int) {
+			if c.execResult == nil {
+				c.Fail("No command has been run")
+				return
+			}
+
+			assert.Equal(c.T, expectedExitCode, c.execResult.ExitCode, "The command '%s' in the pod '%s' exited with code %d, but expected %d. Output:\n%s", c.execResult.Command, c.execResult.PodName, c.execResult.ExitCode, expectedExitCode, c.execResult.Output())
+		})
+
+	})
+}
+
+// RunCommandInPod runs the given command line in a shell (/bin/sh -c) in the given pod.
+// If the container name is empty, the first container of the pod is used.
+//
+// The result is stored in the context, to be used by the following steps.
+// A non-zero exit code is not considered as an error, but is reported in the result.
+// It returns the result of the command, or an error if the command could not be executed.
+func (c *Context) RunCommandInPod(podName string, containerName string, command string) (*ExecResult, error) {
+	// the result of a previous command should not be checked if this one fails
+	c.execResult = nil
+
+	var stdout, stderr bytes.Buffer
+	err := c.ExecInPod(podName, containerName, []string{"/bin/sh", "-c", command}, nil, &stdout, &stderr)
+
+	exitCode, isExitError := exitCodeFromExecError(err)
+	if err != nil && !isExitError {
+		return nil, err
+	}
+
+	c.execResult = &ExecResult{
+		PodName:  podName,
+		Command:  command,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: exitCode,
+	}
+
+	return c.execResult, nil
+}
+
+// ExecInPod executes the given command in the given container of the given pod,
+// using the exec subresource of the pod.
+// If the container name is empty, the first container of the pod is used.
+//
+// The stdin, stdout and stderr streams are optional.
+// It returns an error if the command could not be executed, or if it exited with a non-zero code.
+func (c *Context) ExecInPod(podName string, containerName string, command []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	_, kclient, err := c.Clients()
+	if err != nil {
+		return err
+	}
+
+	namespace, err := c.Namespace()
+	if err != nil {
+		return err
+	}
+
+	config, err := c.ClientConfig()
+	if err != nil {
+		return err
+	}
+
+	if len(containerName) == 0 {
+		pod, err := c.GetPod(podName)
+		if err != nil {
+			return err
+		}
+		if len(pod.Spec.Containers) == 0 {
+			return fmt.Errorf("The pod '%s' has no containers", podName)
+		}
+		containerName = pod.Spec.Containers[0].Name
+	}
+
+	req := kclient.RESTClient.Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(podName).
+		SubResource("exec")
+	req.VersionedParams(&kapi.PodExecOptions{
+		Container: containerName,
+		Command:   command,
+		Stdin:     stdin != nil,
+		Stdout:    stdout != nil,
+		Stderr:    stderr != nil,
+	}, kapi.Scheme)
+
+	executor, err := remotecommand.NewExecutor(config, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+
+	return executor.Stream(stdin, stdout, stderr, false)
+}
+
+// exitCodeRegexp extracts the exit code from the message of a remote command error.
+// Only the known forms of exit errors are matched, for example
+// "error executing remote command: Error executing in Docker Container: 1"
+// or "error executing remote command: error executing command in container: exit status 1"
+var exitCodeRegexp = regexp.MustCompile(`^error executing remote command: (?:.+: )?(?:exit status |Error executing in Docker Container: )(\d+)$`)
+
+// exitCodeFromExecError returns the exit code of a command executed with ExecInPod
+// based on its error, and whether the error was caused by a non-zero exit code
+func exitCodeFromExecError(err error) (int, bool) {
+	if err == nil {
+		return 0, false
+	}
+
+	matches := exitCodeRegexp.FindStringSubmatch(strings.TrimSpace(err.Error()))
+	if len(matches) != 2 {
+		return 0, false
+	}
+
+	exitCode, convErr := strconv.Atoi(matches[1])
+	if convErr != nil {
+		return 0, false
+	}
+
+	return exitCode, true
+}
@@ -0,0 +1,30 @@
+package steps
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExitCodeFromExecError(t *testing.T) {
+	tests := []struct {
+		err              error
+		expectedCode     int
+		expectedExitCode bool
+	}{
+		{nil, 0, false},
+		{errors.New("error executing remote command: Error executing in Docker Container: 1"), 1, true},
+		{errors.New("error executing remote command: Error executing in Docker Container: 127\n"), 127, true},
+		{errors.New("error executing remote command: error executing command in container: exit status 2"), 2, true},
+		{errors.New("error executing remote command: exit status 3"), 3, true},
+		{errors.New("error executing remote command: dial tcp 10.0.0.1:10250: i/o timeout"), 0, false},
+		{errors.New("error executing remote command: container not found 12"), 0, false},
+		{errors.New("exit status 1"), 0, false},
+	}
+
+	for _, test := range tests {
+		code, isExitError := exitCodeFromExecError(test.err)
+		if code != test.expectedCode || isExitError != test.expectedExitCode {
+			t.Errorf("exitCodeFromExecError(%v): expected (%d, %v), but got (%d, %v)", test.err, test.expectedCode, test.expectedExitCode, code, isExitError)
+		}
+	}
+}
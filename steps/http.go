@@ -3,88 +3,488 @@ package steps
 import (
 	"crypto/tls"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/cenkalti/backoff"
 	"github.com/stretchr/testify/assert"
 )
 
+// HTTPConfig is the configuration of the HTTP requests sent by the steps
+type HTTPConfig struct {
+	// Timeout is the maximum duration of a single HTTP request
+	Timeout time.Duration
+
+	// RetryTimeout is the maximum duration to retry HTTP requests that failed
+	// or returned a 5xx status (GET, HEAD and OPTIONS requests only), using an exponential backoff.
+	// If the retries run out on a 5xx status, the last response is returned as-is.
+	// 0 disables the retries.
+	RetryTimeout time.Duration
+}
+
+// DefaultHTTPConfig is the HTTP configuration used at the beginning of each scenario
+var DefaultHTTPConfig = HTTPConfig{
+	Timeout:      5 * time.Second,
+	RetryTimeout: 30 * time.Second,
+}
+
+// httpState is the HTTP state of a scenario:
+// the configuration, the request headers and cookies to use, whether to follow redirects,
+// and the last response received (with its body already read) with the failed attempts of its request
+type httpState struct {
+	config           HTTPConfig
+	requestHeaders   http.Header
+	cookieJar        *recordingCookieJar
+	followRedirects  bool
+	response         *http.Response
+	responseBody     []byte
+	responseDuration time.Duration
+	failedAttempts   []string
+}
+
+// copy returns a copy of the HTTP state,
+// with its own request headers and cookies, that can be changed without changing this state
+func (s httpState) copy() httpState {
+	headers := make(http.Header, len(s.requestHeaders))
+	for name, values := range s.requestHeaders {
+		headers[name] = append([]string(nil), values...)
+	}
+	s.requestHeaders = headers
+	s.cookieJar = s.cookieJar.copy()
+	return s
+}
+
+// recordingCookieJar is a cookie jar that records the cookies it receives,
+// so that it can be copied
+type recordingCookieJar struct {
+	*cookiejar.Jar
+
+	lock    sync.Mutex
+	records []cookieRecord
+}
+
+// cookieRecord is a call of SetCookies on a recordingCookieJar
+type cookieRecord struct {
+	url     *url.URL
+	cookies []*http.Cookie
+}
+
+// newRecordingCookieJar returns a new empty cookie jar
+func newRecordingCookieJar() *recordingCookieJar {
+	// cookiejar.New never returns an error
+	jar, _ := cookiejar.New(nil)
+	return &recordingCookieJar{Jar: jar}
+}
+
+// SetCookies records the cookies and stores them in the jar
+func (j *recordingCookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.lock.Lock()
+	j.records = append(j.records, cookieRecord{url: u, cookies: cookies})
+	j.lock.Unlock()
+
+	j.Jar.SetCookies(u, cookies)
+}
+
+// copy returns a new cookie jar with the same cookies
+func (j *recordingCookieJar) copy() *recordingCookieJar {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	jar := newRecordingCookieJar()
+	for _, record := range j.records {
+		jar.SetCookies(record.url, record.cookies)
+	}
+	return jar
+}
+
 // registers all HTTP-check related steps
 func init() {
-	var currentResp *http.Response
-	requestHeaders := make(http.Header)
-
 	RegisterSteps(func(c *Context) {
 
 		c.Then(`^I should get an HTTP response code (\d+) on path "(.+?)" through the tunnel "(.+?)"$`, func(expectedResponseCode int, path string, tunnelName string) {
-			tunnel := c.GetTunnel(tunnelName)
-			if tunnel == nil {
-				c.Fail("Could not find a tunnel named '%s'", tunnelName)
+			if err := c.SendHTTPRequestThroughTunnel("GET", path, tunnelName, ""); err != nil {
+				c.Fail("HTTP request on path %s through the tunnel '%s' failed: %v", path, tunnelName, err)
 				return
 			}
 
-			url := fmt.Sprintf("http://%s:%v%s", "localhost", tunnel.LocalPort, path)
-			resp, err := c.execHttpGetRequest(url, requestHeaders)
-			currentResp = resp
+			assert.Equal(c.T, expectedResponseCode, c.httpState.response.StatusCode, "Unexpected response code.%s", c.failedAttemptsMessage())
+		})
+
+		c.Then(`^This response has header "(.+?)" equals to "(.+?)"$`, func(name, expectedValue string) {
+			if c.httpState.response == nil {
+				c.Fail("No HTTP response has been received")
+				return
+			}
+
+			assert.Equal(c.T, expectedValue, c.httpState.response.Header.Get(name))
+		})
+
+		c.Then(`^This response has header "(.+?)" matching "(.+?)"$`, func(name, pattern string) {
+			if c.httpState.response == nil {
+				c.Fail("No HTTP response has been received")
+				return
+			}
+
+			re, err := regexp.Compile(pattern)
 			if err != nil {
-				c.Fail("HTTP request on %s failed: %v", url, err)
+				c.Fail("Failed to compile regexp '%s': %v", pattern, err)
 				return
 			}
 
-			assert.Equal(c.T, expectedResponseCode, resp.StatusCode)
-			resp.Body.Close()
+			value := c.httpState.response.Header.Get(name)
+			assert.True(c.T, re.MatchString(value), "The header '%s' with value '%s' does not match '%s'", name, value, pattern)
 		})
 
-		c.Then(`^This response has header "(.+?)" equals to "(.+?)"$`, func(name, expectedValue string) {
-			assert.Equal(c.T, expectedValue, currentResp.Header.Get(name))
+		c.Then(`^This response has header "(.+?)" with values "(.+?)"$`, func(name, expectedValues string) {
+			if c.httpState.response == nil {
+				c.Fail("No HTTP response has been received")
+				return
+			}
+
+			assert.Equal(c.T, strings.Split(expectedValues, ";"), c.httpState.response.Header[http.CanonicalHeaderKey(name)])
 		})
 
 		c.Then(`^This response has no header "(.+?)"$`, func(name string) {
-			assert.NotContains(c.T, name, currentResp.Header)
+			if c.httpState.response == nil {
+				c.Fail("No HTTP response has been received")
+				return
+			}
+
+			values, found := c.httpState.response.Header[http.CanonicalHeaderKey(name)]
+			assert.False(c.T, found, "The response has the header '%s' with values %v", name, values)
 		})
 
 		c.Then(`^I should have the text "(.+?)" on path "(.+?)" through the tunnel "(.+?)"$`, func(expectedContentText string, path string, tunnelName string) {
-			tunnel := c.GetTunnel(tunnelName)
-			if tunnel == nil {
-				c.Fail("Could not find a tunnel named '%s'", tunnelName)
+			if err := c.SendHTTPRequestThroughTunnel("GET", path, tunnelName, ""); err != nil {
+				c.Fail("HTTP request on path %s through the tunnel '%s' failed: %v", path, tunnelName, err)
 				return
 			}
 
-			url := fmt.Sprintf("http://%s:%v%s", "localhost", tunnel.LocalPort, path)
-			resp, err := c.execHttpGetRequest(url, requestHeaders)
+			assert.Contains(c.T, string(c.httpState.responseBody), expectedContentText, "Unexpected response body.%s", c.failedAttemptsMessage())
+		})
+
+		c.When(`^I send a (GET|HEAD|POST|PUT|DELETE|PATCH) request to path "(.+?)" through the tunnel "(.+?)"$`, func(method string, path string, tunnelName string) {
+			if err := c.SendHTTPRequestThroughTunnel(method, path, tunnelName, ""); err != nil {
+				c.Fail("HTTP %s request on path %s through the tunnel '%s' failed: %v", method, path, tunnelName, err)
+				return
+			}
+		})
+
+		c.When(`^I send a (POST|PUT|DELETE|PATCH) request to path "(.+?)" through the tunnel "(.+?)" with body:$`, func(method string, path string, tunnelName string, body string) {
+			if err := c.SendHTTPRequestThroughTunnel(method, path, tunnelName, body); err != nil {
+				c.Fail("HTTP %s request on path %s through the tunnel '%s' failed: %v", method, path, tunnelName, err)
+				return
+			}
+		})
+
+		c.Then(`^the response code should be (\d+)$`, func(expectedResponseCode int) {
+			if c.httpState.response == nil {
+				c.Fail("No HTTP response has been received")
+				return
+			}
+
+			assert.Equal(c.T, expectedResponseCode, c.httpState.response.StatusCode, "Unexpected response code. Response body:\n%s%s", c.httpState.responseBody, c.failedAttemptsMessage())
+		})
+
+		c.Then(`^the response body should contain "(.+?)"$`, func(expectedContentText string) {
+			if c.httpState.response == nil {
+				c.Fail("No HTTP response has been received")
+				return
+			}
+
+			assert.Contains(c.T, string(c.httpState.responseBody), expectedContentText, "Unexpected response body.%s", c.failedAttemptsMessage())
+		})
+
+		c.Then(`^the JSON response at "(.+?)" should equal "(.*?)"$`, func(path string, expectedValue string) {
+			if c.httpState.response == nil {
+				c.Fail("No HTTP response has been received")
+				return
+			}
+
+			var data interface{}
+			if err := json.Unmarshal(c.httpState.responseBody, &data); err != nil {
+				c.Fail("Failed to parse the response body as JSON: %v\n%s", err, c.httpState.responseBody)
+				return
+			}
+
+			value, err := jsonPathValue(data, path)
 			if err != nil {
-				c.Fail("HTTP request on %s failed: %v", url, err)
+				c.Fail("Failed to get the value at '%s' in the JSON response: %v\n%s", path, err, c.httpState.responseBody)
 				return
 			}
-			data, err := ioutil.ReadAll(resp.Body)
 
-			assert.Contains(c.T, string(data), expectedContentText)
-			resp.Body.Close()
+			actualValue, err := formatJSONValue(value)
+			if err != nil {
+				c.Fail("Failed to format the value at '%s' in the JSON response: %v", path, err)
+				return
+			}
+
+			assert.Equal(c.T, expectedValue, actualValue, "Unexpected value at '%s' in the JSON response", path)
+		})
+
+		c.Then(`^the JSON response at "(.+?)" should have (\d+) items?$`, func(path string, expectedLength int) {
+			if c.httpState.response == nil {
+				c.Fail("No HTTP response has been received")
+				return
+			}
+
+			var data interface{}
+			if err := json.Unmarshal(c.httpState.responseBody, &data); err != nil {
+				c.Fail("Failed to parse the response body as JSON: %v\n%s", err, c.httpState.responseBody)
+				return
+			}
+
+			value, err := jsonPathValue(data, path)
+			if err != nil {
+				c.Fail("Failed to get the value at '%s' in the JSON response: %v\n%s", path, err, c.httpState.responseBody)
+				return
+			}
+
+			items, ok := value.([]interface{})
+			if !ok {
+				c.Fail("The value at '%s' in the JSON response is not an array: %v", path, value)
+				return
+			}
+
+			assert.Len(c.T, items, expectedLength, "Unexpected number of items at '%s' in the JSON response", path)
+		})
+
+		c.Then(`^the response time should be less than "(.+?)"$`, func(maxDuration string) {
+			if c.httpState.response == nil {
+				c.Fail("No HTTP response has been received")
+				return
+			}
+
+			maxDurationValue, err := time.ParseDuration(maxDuration)
+			if err != nil {
+				c.Fail("Failed to parse duration '%s': %v", maxDuration, err)
+				return
+			}
+
+			if c.httpState.responseDuration >= maxDurationValue {
+				c.Fail("The response time was %v, but expected less than %v", c.httpState.responseDuration, maxDurationValue)
+				return
+			}
 		})
 
 		c.Given(`^Using request header "(.+?)": "(.+?)"$`, func(name, value string) {
 			for _, v := range strings.Split(value, ";") {
-				requestHeaders.Add(name, v)
+				c.httpState.requestHeaders.Add(name, v)
+			}
+		})
+
+		c.Given(`^HTTP requests are not retried$`, func() {
+			c.httpState.config.RetryTimeout = 0
+		})
+
+		c.Given(`^HTTP requests are retried for "(.+?)"$`, func(retryTimeout string) {
+			duration, err := time.ParseDuration(retryTimeout)
+			if err != nil {
+				c.Fail("Failed to parse duration '%s': %v", retryTimeout, err)
+				return
+			}
+
+			c.httpState.config.RetryTimeout = duration
+		})
+
+		c.Given(`^HTTP requests time out after "(.+?)"$`, func(timeout string) {
+			duration, err := time.ParseDuration(timeout)
+			if err != nil {
+				c.Fail("Failed to parse duration '%s': %v", timeout, err)
+				return
+			}
+
+			c.httpState.config.Timeout = duration
+		})
+
+		c.Given(`^HTTP redirects are (not )?followed$`, func(not string) {
+			c.httpState.followRedirects = len(not) == 0
+		})
+
+		c.When(`^I submit the form to path "(.+?)" through the tunnel "(.+?)" with:$`, func(path string, tunnelName string, fields [][]string) {
+			values := make(url.Values)
+			for _, field := range fields {
+				if len(field) != 2 {
+					c.Fail("Each row of the form should have 2 columns (name and value), but got %v", field)
+					return
+				}
+				values.Add(field[0], field[1])
+			}
+
+			if err := c.SubmitFormThroughTunnel(path, tunnelName, values); err != nil {
+				c.Fail("Failed to submit the form on path %s through the tunnel '%s': %v", path, tunnelName, err)
+				return
+			}
+		})
+
+		c.Then(`^I should have a cookie "([^"]+)"$`, func(name string) {
+			if c.httpState.response == nil {
+				c.Fail("No HTTP response has been received")
+				return
+			}
+
+			if cookie := c.getCookie(name); cookie == nil {
+				c.Fail("No cookie '%s' for %s. Cookies: %v", name, c.httpState.response.Request.URL, c.httpState.cookieJar.Cookies(c.httpState.response.Request.URL))
+				return
+			}
+		})
+
+		c.Then(`^I should have a cookie "([^"]+)" with value "(.*?)"$`, func(name string, expectedValue string) {
+			if c.httpState.response == nil {
+				c.Fail("No HTTP response has been received")
+				return
+			}
+
+			cookie := c.getCookie(name)
+			if cookie == nil {
+				c.Fail("No cookie '%s' for %s. Cookies: %v", name, c.httpState.response.Request.URL, c.httpState.cookieJar.Cookies(c.httpState.response.Request.URL))
+				return
+			}
+
+			assert.Equal(c.T, expectedValue, cookie.Value, "Unexpected value for the cookie '%s'", name)
+		})
+
+		c.Then(`^I should not have a cookie "([^"]+)"$`, func(name string) {
+			if c.httpState.response == nil {
+				c.Fail("No HTTP response has been received")
+				return
+			}
+
+			if cookie := c.getCookie(name); cookie != nil {
+				c.Fail("Unexpected cookie '%s' for %s: %v", name, c.httpState.response.Request.URL, cookie)
+				return
 			}
 		})
 
 	})
 }
 
+// SendHTTPRequestThroughTunnel sends an HTTP request with the given method and body
+// on the given path through the tunnel with the given name, using the request headers of the scenario.
+// The response, its body and the duration of the request are recorded in the context for the following steps.
+// It returns an error if the request failed.
+func (c *Context) SendHTTPRequestThroughTunnel(method string, path string, tunnelName string, body string) error {
+	return c.sendHTTPRequestThroughTunnel(method, path, tunnelName, body, c.httpState.requestHeaders)
+}
+
+// SubmitFormThroughTunnel submits (POST) the given form values on the given path
+// through the tunnel with the given name, using the request headers and cookies of the scenario.
+// The response is recorded in the context for the following steps.
+// It returns an error if the request failed.
+func (c *Context) SubmitFormThroughTunnel(path string, tunnelName string, values url.Values) error {
+	headers := make(http.Header)
+	for name, v := range c.httpState.requestHeaders {
+		headers[name] = v
+	}
+	headers.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return c.sendHTTPRequestThroughTunnel("POST", path, tunnelName, values.Encode(), headers)
+}
+
+// sendHTTPRequestThroughTunnel sends an HTTP request with the given method, body and headers
+// on the given path through the tunnel with the given name,
+// and records the response in the context.
+func (c *Context) sendHTTPRequestThroughTunnel(method string, path string, tunnelName string, body string, headers http.Header) error {
+	c.httpState.response, c.httpState.responseBody, c.httpState.responseDuration = nil, nil, 0
+	c.httpState.failedAttempts = nil
+
+	tunnel := c.GetTunnel(tunnelName)
+	if tunnel == nil {
+		return fmt.Errorf("Could not find a tunnel named '%s'", tunnelName)
+	}
+	if err := tunnel.Err(); err != nil {
+		return fmt.Errorf("The tunnel '%s' is broken: %v", tunnelName, err)
+	}
+
+	url := fmt.Sprintf("http://%s:%v%s", "localhost", tunnel.LocalPort, path)
+	resp, duration, failedAttempts, err := c.execHttpRequestWithRetries(c.newHttpClient(), method, url, headers, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	c.httpState.failedAttempts = failedAttempts
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("Failed to read the response of the HTTP %s request on %s: %v", method, url, err)
+	}
+
+	c.httpState.response, c.httpState.responseBody, c.httpState.responseDuration = resp, data, duration
+	return nil
+}
+
+// failedAttemptsMessage returns a description of the failed attempts of the last HTTP request
+// to append to a failure message, or an empty string if the request has not been retried
+func (c *Context) failedAttemptsMessage() string {
+	if len(c.httpState.failedAttempts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n%d failed attempts:\n%s", len(c.httpState.failedAttempts), strings.Join(c.httpState.failedAttempts, "\n"))
+}
+
+// getCookie returns the cookie with the given name for the URL of the last request, or nil
+func (c *Context) getCookie(name string) *http.Cookie {
+	if c.httpState.response == nil {
+		return nil
+	}
+	for _, cookie := range c.httpState.cookieJar.Cookies(c.httpState.response.Request.URL) {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+// resetHTTPState resets the HTTP state of the context:
+// configuration, request headers, cookies, redirects policy and last response
+func (c *Context) resetHTTPState() {
+	c.httpState = httpState{
+		config:          DefaultHTTPConfig,
+		requestHeaders:  make(http.Header),
+		cookieJar:       newRecordingCookieJar(),
+		followRedirects: true,
+	}
+}
+
 // execHttpGetRequest executes an HTTP GET request on the given URL
 // and returns the response or an error
 // It uses an exponential backoff retry
 func (c *Context) execHttpGetRequest(url string, headers http.Header) (*http.Response, error) {
+	resp, _, err := c.execHttpRequest("GET", url, headers, "")
+	return resp, err
+}
+
+// execHttpRequest executes an HTTP request with the given method and body on the given URL
+// and returns the response and the duration of the (last) request, or an error
+// It uses the configuration, the cookies and the redirects policy of the scenario.
+// Failed requests (and 5xx responses of idempotent requests) are retried with an exponential backoff (if enabled),
+// see execHttpRequestWithClient.
+func (c *Context) execHttpRequest(method string, url string, headers http.Header, body string) (*http.Response, time.Duration, error) {
+	return c.execHttpRequestWithClient(c.newHttpClient(), method, url, headers, body)
+}
+
+// newHttpClient returns a new HTTP client
+// using the configuration, the cookies and the redirects policy of the scenario
+func (c *Context) newHttpClient() *http.Client {
+	config := c.httpState.config
+
 	transport := &http.Transport{
 		DisableKeepAlives:     true,
 		MaxIdleConnsPerHost:   5,
-		ResponseHeaderTimeout: 10 * time.Second,
+		ResponseHeaderTimeout: config.Timeout,
 		Dial: (&net.Dialer{
-			Timeout: 5 * time.Second,
+			Timeout: config.Timeout,
 		}).Dial,
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: true,
@@ -92,11 +492,118 @@ func (c *Context) execHttpGetRequest(url string, headers http.Header) (*http.Res
 	}
 	client := &http.Client{
 		Transport: transport,
-		Timeout:   5 * time.Second,
+		Jar:       c.httpState.cookieJar,
+		Timeout:   config.Timeout,
+	}
+	if !c.httpState.followRedirects {
+		client.CheckRedirect = doNotFollowRedirects
+	}
+	return client
+}
+
+// errRedirectNotFollowed is returned by the doNotFollowRedirects policy
+// to stop the client on the redirect response
+var errRedirectNotFollowed = errors.New("Redirect not followed")
+
+// doNotFollowRedirects is an http.Client CheckRedirect policy
+// that returns the redirect response instead of following it
+// (see unwrapRedirectNotFollowed)
+func doNotFollowRedirects(req *http.Request, via []*http.Request) error {
+	return errRedirectNotFollowed
+}
+
+// unwrapRedirectNotFollowed returns the redirect response (and no error)
+// if the given error was caused by the doNotFollowRedirects policy.
+// In this case the client returns both the redirect response and the error,
+// and the body of the response has already been closed, so it is replaced by an empty body.
+func unwrapRedirectNotFollowed(resp *http.Response, err error) (*http.Response, error) {
+	urlErr, ok := err.(*url.Error)
+	if !ok || urlErr.Err != errRedirectNotFollowed || resp == nil {
+		return resp, err
+	}
+
+	resp.Body = ioutil.NopCloser(strings.NewReader(""))
+	return resp, nil
+}
+
+// execHttpRequestWithClient executes an HTTP request with the given client, method and body on the given URL
+// and returns the response and the duration of the (last) request, or an error
+// Failed requests are retried with an exponential backoff (if enabled in the scenario),
+// and each failed attempt is reported in the returned error.
+// 5xx responses are also retried, but only for idempotent methods (see isIdempotentMethod):
+// if the retries run out, the last 5xx response is returned.
+func (c *Context) execHttpRequestWithClient(client *http.Client, method string, url string, headers http.Header, body string) (*http.Response, time.Duration, error) {
+	resp, duration, _, err := c.execHttpRequestWithRetries(client, method, url, headers, body)
+	return resp, duration, err
+}
+
+// execHttpRequestWithRetries is like execHttpRequestWithClient,
+// but it also returns the failed attempts that were retried before the returned response
+// (including the 5xx responses, when the retries ran out on a 5xx response)
+func (c *Context) execHttpRequestWithRetries(client *http.Client, method string, url string, headers http.Header, body string) (*http.Response, time.Duration, []string, error) {
+	config := c.httpState.config
+
+	var b backoff.BackOff = &backoff.StopBackOff{}
+	if config.RetryTimeout > 0 {
+		exponentialBackOff := backoff.NewExponentialBackOff()
+		exponentialBackOff.MaxElapsedTime = config.RetryTimeout
+		b = exponentialBackOff
+	}
+	retryServerErrors := isIdempotentMethod(method)
+
+	var resp *http.Response
+	var duration time.Duration
+	var err error
+	attempts := []string{}
+	start := time.Now()
+
+	ticker := backoff.NewTicker(b)
+	for range ticker.C {
+		// the previous response is a 5xx that we are retrying
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		resp, duration, err = execHttpRequestAttempt(client, method, url, headers, body)
+		switch {
+		case err != nil:
+			attempts = append(attempts, fmt.Sprintf("attempt %d after %v: %v", len(attempts)+1, time.Since(start), err))
+		case retryServerErrors && resp.StatusCode >= 500:
+			attempts = append(attempts, fmt.Sprintf("attempt %d after %v: Invalid status: %s", len(attempts)+1, time.Since(start), resp.Status))
+		default:
+			ticker.Stop()
+			return resp, duration, attempts, nil
+		}
+	}
+
+	if err != nil {
+		if len(attempts) > 1 {
+			err = fmt.Errorf("%v\n%d attempts in %v:\n%s", err, len(attempts), time.Since(start), strings.Join(attempts, "\n"))
+		}
+		return nil, duration, attempts, err
+	}
+
+	// the retries ran out on a 5xx response
+	return resp, duration, attempts, nil
+}
+
+// isIdempotentMethod returns true if a request with the given HTTP method
+// can safely be sent again when the server returned an error
+func isIdempotentMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case "GET", "HEAD", "OPTIONS":
+		return true
 	}
-	req, err := http.NewRequest("GET", url, nil)
+	return false
+}
+
+// execHttpRequestAttempt executes a single HTTP request with the given client,
+// and returns the response and the duration of the request, or an error.
+func execHttpRequestAttempt(client *http.Client, method string, url string, headers http.Header, body string) (*http.Response, time.Duration, error) {
+	// the request is re-created for each attempt, because its body can only be read once
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	for name, values := range headers {
 		for _, value := range values {
@@ -104,22 +611,68 @@ func (c *Context) execHttpGetRequest(url string, headers http.Header) (*http.Res
 		}
 	}
 
-	var resp *http.Response
-	err = c.ExecWithExponentialBackoff(func() (err error) {
-		resp, err = client.Do(req)
-		if err == nil {
-			if resp.StatusCode >= 500 {
-				err = fmt.Errorf("Invalid status: %s", resp.Status)
+	start := time.Now()
+	resp, err := unwrapRedirectNotFollowed(client.Do(req))
+	duration := time.Since(start)
+	if err != nil {
+		return nil, duration, err
+	}
+	return resp, duration, nil
+}
+
+// jsonPathSegmentRegexp matches a segment of a JSON path, such as "items" or "items[0]"
+var jsonPathSegmentRegexp = regexp.MustCompile(`^([^\[\]]*)((?:\[\d+\])*)$`)
+
+// jsonPathValue returns the value at the given path in the given (unmarshalled) JSON data.
+// The path is a dot-separated list of fields, with optional array indexes, for example "items[0].name".
+func jsonPathValue(data interface{}, path string) (interface{}, error) {
+	value := data
+	for _, segment := range strings.Split(path, ".") {
+		matches := jsonPathSegmentRegexp.FindStringSubmatch(segment)
+		if matches == nil {
+			return nil, fmt.Errorf("Invalid path segment '%s'", segment)
+		}
+
+		if field := matches[1]; len(field) > 0 {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("Can't get the field '%s' of a non-object value: %v", field, value)
+			}
+			if value, ok = object[field]; !ok {
+				return nil, fmt.Errorf("No field '%s' in %v", field, object)
 			}
 		}
-		return
-	})
 
-	if err != nil {
-		return nil, err
+		for _, index := range strings.FieldsFunc(matches[2], func(r rune) bool { return r == '[' || r == ']' }) {
+			i, err := strconv.Atoi(index)
+			if err != nil {
+				return nil, err
+			}
+			array, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("Can't get the index %d of a non-array value: %v", i, value)
+			}
+			if i >= len(array) {
+				return nil, fmt.Errorf("Index %d is out of range (%d items)", i, len(array))
+			}
+			value = array[i]
+		}
 	}
+	return value, nil
+}
 
-	return resp, nil
+// formatJSONValue returns the given JSON value as a string:
+// strings are returned as-is, other values are encoded as JSON
+func formatJSONValue(value interface{}) (string, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
 }
 
 // See 2 (end of page 4) http://www.ietf.org/rfc/rfc2617.txt
@@ -0,0 +1,223 @@
+package steps
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJSONPathValue(t *testing.T) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(`{"name": "hello", "spec": {"replicas": 2, "ports": [{"port": 80}, {"port": 443}]}, "matrix": [[1, 2], [3, 4]], "items": []}`), &data); err != nil {
+		t.Fatalf("Failed to unmarshal the test data: %v", err)
+	}
+
+	tests := []struct {
+		path          string
+		expectedValue interface{}
+		expectedError bool
+	}{
+		{"name", "hello", false},
+		{"spec.replicas", float64(2), false},
+		{"spec.ports[1].port", float64(443), false},
+		{"spec.ports[0]", map[string]interface{}{"port": float64(80)}, false},
+		{"matrix[1][0]", float64(3), false},
+		{"items", []interface{}{}, false},
+		{"missing", nil, true},
+		{"name.first", nil, true},
+		{"spec.ports[2]", nil, true},
+		{"spec[0]", nil, true},
+		{"spec.ports[x]", nil, true},
+	}
+
+	for _, test := range tests {
+		value, err := jsonPathValue(data, test.path)
+		if test.expectedError {
+			if err == nil {
+				t.Errorf("jsonPathValue(%s): expected an error, but got %v", test.path, value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("jsonPathValue(%s): unexpected error: %v", test.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.expectedValue, value) {
+			t.Errorf("jsonPathValue(%s): expected %v, but got %v", test.path, test.expectedValue, value)
+		}
+	}
+}
+
+func TestFormatJSONValue(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{"hello", "hello"},
+		{float64(2), "2"},
+		{true, "true"},
+		{nil, "null"},
+		{[]interface{}{"a", float64(1)}, `["a",1]`},
+		{map[string]interface{}{"port": float64(80)}, `{"port":80}`},
+	}
+
+	for _, test := range tests {
+		value, err := formatJSONValue(test.value)
+		if err != nil {
+			t.Errorf("formatJSONValue(%v): unexpected error: %v", test.value, err)
+			continue
+		}
+		if value != test.expected {
+			t.Errorf("formatJSONValue(%v): expected '%s', but got '%s'", test.value, test.expected, value)
+		}
+	}
+}
+
+func TestUnwrapRedirectNotFollowed(t *testing.T) {
+	redirectResp := &http.Response{StatusCode: 302}
+	otherErr := errors.New("connection refused")
+
+	tests := []struct {
+		resp          *http.Response
+		err           error
+		expectedResp  *http.Response
+		expectedError error
+	}{
+		{redirectResp, nil, redirectResp, nil},
+		{redirectResp, &url.Error{Op: "Get", URL: "http://example.com/", Err: errRedirectNotFollowed}, redirectResp, nil},
+		{nil, otherErr, nil, otherErr},
+	}
+
+	for _, test := range tests {
+		resp, err := unwrapRedirectNotFollowed(test.resp, test.err)
+		if resp != test.expectedResp || err != test.expectedError {
+			t.Errorf("unwrapRedirectNotFollowed(%v): expected (%v, %v), but got (%v, %v)", test.err, test.expectedResp, test.expectedError, resp, err)
+		}
+	}
+}
+
+func TestDoNotFollowRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusFound)
+			return
+		}
+		w.Write([]byte("new"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{CheckRedirect: doNotFollowRedirects}
+	resp, _, err := execHttpRequestAttempt(client, "GET", server.URL+"/old", make(http.Header), "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("Expected the status %d, but got %d", http.StatusFound, resp.StatusCode)
+	}
+	if location := resp.Header.Get("Location"); location != "/new" {
+		t.Errorf("Expected the location '/new', but got '%s'", location)
+	}
+	if _, err = ioutil.ReadAll(resp.Body); err != nil {
+		t.Errorf("Failed to read the body of the redirect response: %v", err)
+	}
+}
+
+func TestIsIdempotentMethod(t *testing.T) {
+	tests := []struct {
+		method   string
+		expected bool
+	}{
+		{"GET", true},
+		{"get", true},
+		{"HEAD", true},
+		{"OPTIONS", true},
+		{"POST", false},
+		{"PUT", false},
+		{"PATCH", false},
+		{"DELETE", false},
+	}
+
+	for _, test := range tests {
+		if idempotent := isIdempotentMethod(test.method); idempotent != test.expected {
+			t.Errorf("isIdempotentMethod(%s): expected %v, but got %v", test.method, test.expected, idempotent)
+		}
+	}
+}
+
+func TestExecHttpRequestWithServerErrors(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		method        string
+		retryTimeout  time.Duration
+		expectRetries bool
+	}{
+		{"GET", 0, false},
+		{"GET", 2 * time.Second, true},
+		{"POST", 2 * time.Second, false},
+	}
+
+	for _, test := range tests {
+		c := &Context{}
+		c.resetHTTPState()
+		c.httpState.config.RetryTimeout = test.retryTimeout
+
+		atomic.StoreInt32(&requests, 0)
+		resp, _, failedAttempts, err := c.execHttpRequestWithRetries(c.newHttpClient(), test.method, server.URL, make(http.Header), "")
+		if err != nil {
+			t.Errorf("%s with retry timeout %v: unexpected error: %v", test.method, test.retryTimeout, err)
+			continue
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusServiceUnavailable || string(body) != "unavailable\n" {
+			t.Errorf("%s with retry timeout %v: expected the last 503 response, but got %d '%s'", test.method, test.retryTimeout, resp.StatusCode, body)
+		}
+		if retried := atomic.LoadInt32(&requests) > 1; retried != test.expectRetries {
+			t.Errorf("%s with retry timeout %v: expected retries: %v, but got %d requests", test.method, test.retryTimeout, test.expectRetries, requests)
+		}
+		if test.expectRetries && len(failedAttempts) != int(atomic.LoadInt32(&requests)) {
+			t.Errorf("%s with retry timeout %v: expected %d failed attempts, but got %v", test.method, test.retryTimeout, requests, failedAttempts)
+		}
+	}
+}
+
+func TestHTTPStateCopy(t *testing.T) {
+	u, _ := url.Parse("http://example.com/")
+
+	state := httpState{
+		requestHeaders: http.Header{"X-Background": {"true"}},
+		cookieJar:      newRecordingCookieJar(),
+	}
+	state.cookieJar.SetCookies(u, []*http.Cookie{{Name: "session", Value: "background"}})
+
+	copied := state.copy()
+	copied.requestHeaders.Add("X-Scenario", "true")
+	copied.requestHeaders.Add("X-Background", "false")
+	copied.cookieJar.SetCookies(u, []*http.Cookie{{Name: "scenario", Value: "true"}})
+
+	if !reflect.DeepEqual(http.Header{"X-Background": {"true"}}, state.requestHeaders) {
+		t.Errorf("Expected the original headers to be unchanged, but got %v", state.requestHeaders)
+	}
+	if cookies := state.cookieJar.Cookies(u); len(cookies) != 1 || cookies[0].Name != "session" {
+		t.Errorf("Expected only the cookie 'session' in the original jar, but got %v", cookies)
+	}
+	if cookies := copied.cookieJar.Cookies(u); len(cookies) != 2 {
+		t.Errorf("Expected the cookies 'session' and 'scenario' in the copied jar, but got %v", cookies)
+	}
+}
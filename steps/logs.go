@@ -0,0 +1,164 @@
+package steps
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// registers all logs related steps
+func init() {
+	RegisterSteps(func(c *Context) {
+
+		c.Then(`^the logs of the pod "(.+?)" should contain "([^"]+?)"$`, func(podName string, expectedText string) {
+			logs, err := c.GetPodLogs(podName, &kapi.PodLogOptions{})
+			if err != nil {
+				c.Fail("Failed to get logs of the pod '%s': %v", podName, err)
+				return
+			}
+
+			assert.Contains(c.T, logs, expectedText, "The logs of the pod '%s' do not contain '%s'", podName, expectedText)
+		})
+
+		c.Then(`^the logs of the container "(.+?)" of the pod "(.+?)" should contain "([^"]+?)"$`, func(containerName string, podName string, expectedText string) {
+			logs, err := c.GetPodLogs(podName, &kapi.PodLogOptions{Container: containerName})
+			if err != nil {
+				c.Fail("Failed to get logs of the container '%s' of the pod '%s': %v", containerName, podName, err)
+				return
+			}
+
+			assert.Contains(c.T, logs, expectedText, "The logs of the container '%s' of the pod '%s' do not contain '%s'", containerName, podName, expectedText)
+		})
+
+		c.Then(`^the previous logs of the pod "(.+?)" should contain "([^"]+?)"$`, func(podName string, expectedText string) {
+			logs, err := c.GetPodLogs(podName, &kapi.PodLogOptions{Previous: true})
+			if err != nil {
+				c.Fail("Failed to get previous logs of the pod '%s': %v", podName, err)
+				return
+			}
+
+			assert.Contains(c.T, logs, expectedText, "The previous logs of the pod '%s' do not contain '%s'", podName, expectedText)
+		})
+
+		c.Then(`^the previous logs of the container "(.+?)" of the pod "(.+?)" should contain "([^"]+?)"$`, func(containerName string, podName string, expectedText string) {
+			logs, err := c.GetPodLogs(podName, &kapi.PodLogOptions{Container: containerName, Previous: true})
+			if err != nil {
+				c.Fail("Failed to get previous logs of the container '%s' of the pod '%s': %v", containerName, podName, err)
+				return
+			}
+
+			assert.Contains(c.T, logs, expectedText, "The previous logs of the container '%s' of the pod '%s' do not contain '%s'", containerName, podName, expectedText)
+		})
+
+		c.Then(`^the logs of the pod "(.+?)" should contain "(.+?)" within "(.+?)"$`, func(podName string, expectedText string, timeout string) {
+			timeoutDuration, err := time.ParseDuration(timeout)
+			if err != nil {
+				c.Fail("Failed to parse duration '%s': %v", timeout, err)
+				return
+			}
+
+			found, err := c.WaitForPodLogs(podName, &kapi.PodLogOptions{}, expectedText, timeoutDuration)
+			if err != nil {
+				c.Fail("Failed to follow logs of the pod '%s': %v", podName, err)
+				return
+			}
+
+			if !found {
+				c.Fail("The logs of the pod '%s' do not contain '%s' after %s", podName, expectedText, timeout)
+				return
+			}
+		})
+
+		c.Then(`^the logs of a pod of "(.+?)" should contain "(.+?)" within "(.+?)"$`, func(dcName string, expectedText string, timeout string) {
+			timeoutDuration, err := time.ParseDuration(timeout)
+			if err != nil {
+				c.Fail("Failed to parse duration '%s': %v", timeout, err)
+				return
+			}
+
+			pod, err := c.GetRunningPodOfLatestDeployment(dcName)
+			if err != nil {
+				c.Fail("Failed to find a running pod of the latest deployment of '%s': %v", dcName, err)
+				return
+			}
+
+			found, err := c.WaitForPodLogs(pod.Name, &kapi.PodLogOptions{}, expectedText, timeoutDuration)
+			if err != nil {
+				c.Fail("Failed to follow logs of the pod '%s': %v", pod.Name, err)
+				return
+			}
+
+			if !found {
+				c.Fail("The logs of the pod '%s' (latest deployment of '%s') do not contain '%s' after %s", pod.Name, dcName, expectedText, timeout)
+				return
+			}
+		})
+
+	})
+}
+
+// WaitForPodLogs follows the logs of the pod with the given name (using the given log options)
+// until a line contains the given text, or until the given timeout duration.
+//
+// It returns true if the text was found, false if it was not found before the timeout,
+// or an error if the logs could not be followed before the timeout (for example if the container did not start).
+func (c *Context) WaitForPodLogs(podName string, options *kapi.PodLogOptions, expectedText string, timeout time.Duration) (bool, error) {
+	_, kclient, err := c.Clients()
+	if err != nil {
+		return false, err
+	}
+
+	namespace, err := c.Namespace()
+	if err != nil {
+		return false, err
+	}
+
+	followOptions := *options
+	followOptions.Follow = true
+
+	deadline := time.After(timeout)
+
+	// the logs can't be streamed until the container has started, so retry until the timeout
+	var readCloser io.ReadCloser
+	for {
+		req, err := kclient.PodLogs(namespace).Get(podName, &followOptions)
+		if err == nil {
+			readCloser, err = req.Stream()
+		}
+		if err == nil {
+			break
+		}
+
+		select {
+		case <-time.After(waitPollInterval):
+		case <-deadline:
+			return false, err
+		}
+	}
+	// closing the stream also stops the scanning goroutine
+	defer readCloser.Close()
+
+	foundChan := make(chan bool, 1)
+	go func() {
+		scanner := bufio.NewScanner(readCloser)
+		for scanner.Scan() {
+			if strings.Contains(scanner.Text(), expectedText) {
+				foundChan <- true
+				return
+			}
+		}
+		foundChan <- false
+	}()
+
+	select {
+	case found := <-foundChan:
+		return found, nil
+	case <-deadline:
+		return false, nil
+	}
+}
@@ -2,12 +2,17 @@ package steps
 
 import (
 	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
 
 	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	deployutil "github.com/openshift/origin/pkg/deploy/util"
 
 	kapi "k8s.io/kubernetes/pkg/api"
 	"k8s.io/kubernetes/pkg/fields"
 	"k8s.io/kubernetes/pkg/labels"
+	"k8s.io/kubernetes/pkg/watch"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -26,30 +31,72 @@ func init() {
 			assert.Equal(c.T, podName, pod.Name)
 		})
 
-		c.When(`^I open a tunnel "(.+?)" to a pod of the latest deployment of "(.+?)" on port (\d+)$`, func(tunnelName string, dcName string, targetPort int) {
-			dc, err := c.GetDeploymentConfig(dcName)
+		c.Then(`^all pods of "(.+?)" should be ready$`, func(dcName string) {
+			pods, err := c.GetPodsOfDeploymentConfig(dcName)
 			if err != nil {
-				c.Fail("Failed to get Deployment Config '%s': %v", dcName, err)
+				c.Fail("Failed to get pods of '%s': %v", dcName, err)
+				return
+			}
+
+			if len(pods) == 0 {
+				c.Fail("Could not find any pod of '%s'", dcName)
 				return
 			}
 
-			deployment := fmt.Sprintf("%v-%v", dc.Name, dc.Status.LatestVersion)
-			deploymentSelector := labels.Set{deployapi.DeploymentLabel: deployment}.AsSelector()
-			pods, err := c.GetPods(deploymentSelector)
+			for i := range pods {
+				if !kapi.IsPodReady(&pods[i]) {
+					c.Fail("The pod '%s' of '%s' is not ready (phase '%s'):\n%s", pods[i].Name, dcName, pods[i].Status.Phase, formatContainerStatuses(pods[i].Status.ContainerStatuses))
+					return
+				}
+			}
+		})
+
+		c.Then(`^all pods of "(.+?)" should have restarted less than (\d+) times?$`, func(dcName string, maxRestarts int) {
+			pods, err := c.GetPodsOfDeploymentConfig(dcName)
 			if err != nil {
-				c.Fail("Failed to get pods for label selector %+v: %v", deploymentSelector, err)
+				c.Fail("Failed to get pods of '%s': %v", dcName, err)
 				return
 			}
 
-			var pod *kapi.Pod
-			for _, p := range pods.Items {
-				if p.Status.Phase == kapi.PodRunning {
-					pod = &p
-					break
+			if len(pods) == 0 {
+				c.Fail("Could not find any pod of '%s'", dcName)
+				return
+			}
+
+			for _, pod := range pods {
+				for _, status := range pod.Status.ContainerStatuses {
+					if status.RestartCount >= maxRestarts {
+						c.Fail("The container '%s' of the pod '%s' has restarted %d times, but expected less than %d restarts:\n%s", status.Name, pod.Name, status.RestartCount, maxRestarts, formatContainerStatuses(pod.Status.ContainerStatuses))
+						return
+					}
 				}
 			}
-			if pod == nil {
-				c.Fail("Could not find any running pod for label selector %+v: %v", deploymentSelector, err)
+		})
+
+		c.Then(`^all pods of "(.+?)" should be in phase "(.+?)" for at least "(.+?)"$`, func(dcName string, expectedPhase string, duration string) {
+			parsedDuration, err := time.ParseDuration(duration)
+			if err != nil {
+				c.Fail("Failed to parse duration '%s': %v", duration, err)
+				return
+			}
+
+			startTime := time.Now()
+			pod, err := c.FindPodNotInPhase(dcName, kapi.PodPhase(expectedPhase), parsedDuration)
+			if err != nil {
+				c.Fail("Failed to check the phase of the pods of '%s': %v", dcName, err)
+				return
+			}
+
+			if pod != nil {
+				c.Fail("The pod '%s' of '%s' is in phase '%s' after %s, but expected phase '%s' for at least %s:\n%s", pod.Name, dcName, pod.Status.Phase, time.Now().Sub(startTime), expectedPhase, duration, formatContainerStatuses(pod.Status.ContainerStatuses))
+				return
+			}
+		})
+
+		c.When(`^I open a tunnel "(.+?)" to a pod of the latest deployment of "(.+?)" on port (\d+)$`, func(tunnelName string, dcName string, targetPort int) {
+			pod, err := c.GetRunningPodOfLatestDeployment(dcName)
+			if err != nil {
+				c.Fail("Failed to find a running pod of the latest deployment of '%s': %v", dcName, err)
 				return
 			}
 
@@ -60,6 +107,20 @@ func init() {
 			}
 		})
 
+		c.When(`^I open a tunnel "(.+?)" to a pod of the latest deployment of "(.+?)" on port (\d+) with local port (\d+)$`, func(tunnelName string, dcName string, targetPort int, localPort int) {
+			pod, err := c.GetRunningPodOfLatestDeployment(dcName)
+			if err != nil {
+				c.Fail("Failed to find a running pod of the latest deployment of '%s': %v", dcName, err)
+				return
+			}
+
+			_, err = c.OpenTunnelOnLocalPort(tunnelName, pod.Name, localPort, targetPort)
+			if err != nil {
+				c.Fail("Failed to open tunnel %s: %v", tunnelName, err)
+				return
+			}
+		})
+
 		c.Then(`^I close the tunnel "(.+?)"$`, func(tunnelName string) {
 			c.CloseTunnel(tunnelName)
 		})
@@ -106,3 +167,213 @@ func (c *Context) GetPods(labelSelector labels.Selector) (*kapi.PodList, error)
 
 	return pods, nil
 }
+
+// GetRunningPodOfLatestDeployment gets a running pod of the latest deployment
+// of the DeploymentConfig with the given name, or returns an error
+func (c *Context) GetRunningPodOfLatestDeployment(dcName string) (*kapi.Pod, error) {
+	dc, err := c.GetDeploymentConfig(dcName)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment := deployutil.LatestDeploymentNameForConfig(dc)
+	deploymentSelector := labels.Set{deployapi.DeploymentLabel: deployment}.AsSelector()
+	return c.GetRunningPod(deploymentSelector)
+}
+
+// GetRunningPod gets a running pod matching the given label selector, or returns an error
+func (c *Context) GetRunningPod(labelSelector labels.Selector) (*kapi.Pod, error) {
+	pods, err := c.GetPods(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pods.Items {
+		if pods.Items[i].Status.Phase == kapi.PodRunning {
+			return &pods.Items[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("Could not find any running pod for label selector %v", labelSelector)
+}
+
+// GetPodsOfDeploymentConfig gets the pods created by the deployments of the DeploymentConfig
+// with the given name, or returns an error.
+// The deployer and hook pods are not included.
+func (c *Context) GetPodsOfDeploymentConfig(dcName string) ([]kapi.Pod, error) {
+	// the pods are labelled with the deploymentconfig label,
+	// not with the annotation used by deployutil.ConfigSelector on the deployments
+	dcSelector := labels.Set{deployapi.DeploymentConfigLabel: dcName}.AsSelector()
+	podList, err := c.GetPods(dcSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	pods := []kapi.Pod{}
+	for _, pod := range podList.Items {
+		if _, isDeployerPod := pod.Labels[deployapi.DeployerPodForDeploymentLabel]; !isDeployerPod {
+			pods = append(pods, pod)
+		}
+	}
+
+	return pods, nil
+}
+
+// GetPodLogs gets the logs of the pod with the given name, using the given log options
+// It returns the logs, or an error
+func (c *Context) GetPodLogs(podName string, options *kapi.PodLogOptions) (string, error) {
+	_, kclient, err := c.Clients()
+	if err != nil {
+		return "", err
+	}
+
+	namespace, err := c.Namespace()
+	if err != nil {
+		return "", err
+	}
+
+	req, err := kclient.PodLogs(namespace).Get(podName, options)
+	if err != nil {
+		return "", err
+	}
+
+	readCloser, err := req.Stream()
+	if err != nil {
+		return "", err
+	}
+	defer readCloser.Close()
+
+	bytes, err := ioutil.ReadAll(readCloser)
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes), nil
+}
+
+// WaitForReadyPods waits until the number of ready pods matching the given label selector
+// is equal to the expected number of pods, or until the given timeout duration.
+//
+// A pod is ready if its Ready condition is true.
+//
+// It returns the number of ready pods found the last time it checked, or an error.
+func (c *Context) WaitForReadyPods(labelSelector labels.Selector, expectedReadyPods int, timeout time.Duration) (int, error) {
+	_, kclient, err := c.Clients()
+	if err != nil {
+		return 0, err
+	}
+
+	namespace, err := c.Namespace()
+	if err != nil {
+		return 0, err
+	}
+
+	var readyPods int
+	check := func() (bool, error) {
+		var pods *kapi.PodList
+		err := c.ExecWithExponentialBackoff(func() (err error) {
+			pods, err = c.GetPods(labelSelector)
+			return
+		})
+		if err != nil {
+			return false, err
+		}
+
+		readyPods = countReadyPods(pods)
+		return readyPods == expectedReadyPods, nil
+	}
+
+	watchFunc := func() (watch.Interface, error) {
+		return kclient.Pods(namespace).Watch(labelSelector, fields.Everything(), "")
+	}
+
+	err = c.waitUntil(check, watchFunc, timeout)
+	if err != nil && err != ErrWaitTimeout {
+		return 0, err
+	}
+
+	return readyPods, nil
+}
+
+// FindPodNotInPhase watches the pods of the given deployment config during the given duration,
+// and returns the first pod found in another phase than the given one,
+// or nil if all the pods stayed in the given phase.
+// It returns an error if there is no pod.
+func (c *Context) FindPodNotInPhase(dcName string, phase kapi.PodPhase, duration time.Duration) (*kapi.Pod, error) {
+	_, kclient, err := c.Clients()
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, err := c.Namespace()
+	if err != nil {
+		return nil, err
+	}
+
+	var podNotInPhase *kapi.Pod
+	check := func() (bool, error) {
+		var pods []kapi.Pod
+		err := c.ExecWithExponentialBackoff(func() (err error) {
+			pods, err = c.GetPodsOfDeploymentConfig(dcName)
+			return
+		})
+		if err != nil {
+			return false, err
+		}
+
+		if len(pods) == 0 {
+			return false, fmt.Errorf("Could not find any pod of '%s'", dcName)
+		}
+
+		for i := range pods {
+			if pods[i].Status.Phase != phase {
+				podNotInPhase = &pods[i]
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	watchFunc := func() (watch.Interface, error) {
+		dcSelector := labels.Set{deployapi.DeploymentConfigLabel: dcName}.AsSelector()
+		return kclient.Pods(namespace).Watch(dcSelector, fields.Everything(), "")
+	}
+
+	// the pods stayed in the phase if the check never succeeded
+	err = c.waitUntil(check, watchFunc, duration)
+	if err != nil && err != ErrWaitTimeout {
+		return nil, err
+	}
+
+	return podNotInPhase, nil
+}
+
+// countReadyPods returns the number of ready pods in the given list
+func countReadyPods(pods *kapi.PodList) int {
+	var readyPods int
+	for i := range pods.Items {
+		if kapi.IsPodReady(&pods.Items[i]) {
+			readyPods++
+		}
+	}
+	return readyPods
+}
+
+// formatContainerStatuses formats the given container statuses in a human-readable way,
+// one container per line
+func formatContainerStatuses(statuses []kapi.ContainerStatus) string {
+	lines := []string{}
+	for _, status := range statuses {
+		var state string
+		switch {
+		case status.State.Running != nil:
+			state = "running"
+		case status.State.Waiting != nil:
+			state = fmt.Sprintf("waiting (%s)", status.State.Waiting.Reason)
+		case status.State.Terminated != nil:
+			state = fmt.Sprintf("terminated (%s, exit code %d)", status.State.Terminated.Reason, status.State.Terminated.ExitCode)
+		}
+		lines = append(lines, fmt.Sprintf("container '%s': %s, ready: %v, restarts: %d", status.Name, state, status.Ready, status.RestartCount))
+	}
+	return strings.Join(lines, "\n")
+}
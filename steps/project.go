@@ -1,7 +1,7 @@
 package steps
 
 import (
-	"time"
+	"fmt"
 
 	projectapi "github.com/openshift/origin/pkg/project/api"
 
@@ -142,18 +142,16 @@ func (c *Context) DeleteProject(projectName string) error {
 	}
 
 	// make sure the project has been deleted
-	for {
+	// we can't watch the project: once deleted, getting it is forbidden
+	err = c.waitUntil(func() (bool, error) {
 		exists, err := c.ProjectExists(projectName)
-		if err != nil {
-			return err
-		}
-		if !exists {
-			break
-		}
-		time.Sleep(1 * time.Second)
+		return !exists, err
+	}, nil, defaultWaitTimeout)
+	if err == ErrWaitTimeout {
+		return fmt.Errorf("The project '%s' still exists after %v", projectName, defaultWaitTimeout)
 	}
 
-	return nil
+	return err
 }
 
 // CreateNewProject creates a new project with the given name, or returns an error
@@ -175,16 +173,12 @@ func (c *Context) CreateNewProject(projectName string) error {
 	}
 
 	// make sure the project has been created
-	for {
-		exists, err := c.ProjectExists(projectName)
-		if err != nil {
-			return err
-		}
-		if exists {
-			break
-		}
-		time.Sleep(1 * time.Second)
+	err = c.waitUntil(func() (bool, error) {
+		return c.ProjectExists(projectName)
+	}, nil, defaultWaitTimeout)
+	if err == ErrWaitTimeout {
+		return fmt.Errorf("The project '%s' does not exist after %v", projectName, defaultWaitTimeout)
 	}
 
-	return nil
+	return err
 }
@@ -1,13 +1,22 @@
 package steps
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+
 	"k8s.io/kubernetes/pkg/api"
+	kerrors "k8s.io/kubernetes/pkg/api/errors"
 	"k8s.io/kubernetes/pkg/api/validation"
 	"k8s.io/kubernetes/pkg/kubectl"
 	"k8s.io/kubernetes/pkg/kubectl/resource"
+	"k8s.io/kubernetes/pkg/util/strategicpatch"
+
+	"github.com/stretchr/testify/assert"
 )
 
 // registers all resource related steps
@@ -55,6 +64,78 @@ func init() {
 			}
 		})
 
+		c.When(`^I apply resources from the file "(.+?)"$`, func(fileName string) {
+			expandedFileName := os.ExpandEnv(fileName)
+			if expandedFileName == "" {
+				c.Fail("File name '%s' (expanded to '%s') is empty !", fileName, expandedFileName)
+				return
+			}
+			if _, err := os.Stat(expandedFileName); err != nil {
+				c.Fail("File '%s' (expanded to '%s') does not exists: %v", fileName, expandedFileName, err)
+				return
+			}
+
+			r, err := c.ParseResource(expandedFileName)
+			if err != nil {
+				c.Fail("Failed to parse file '%s' (expanded to '%s'): %v", fileName, expandedFileName, err)
+				return
+			}
+
+			if err = r.Visit(ApplyResource); err != nil {
+				c.Fail("Failed to apply resource from file '%s' (expanded to '%s'): %v", fileName, expandedFileName, err)
+				return
+			}
+		})
+
+		c.When(`^I replace resources from the file "(.+?)"$`, func(fileName string) {
+			expandedFileName := os.ExpandEnv(fileName)
+			if expandedFileName == "" {
+				c.Fail("File name '%s' (expanded to '%s') is empty !", fileName, expandedFileName)
+				return
+			}
+			if _, err := os.Stat(expandedFileName); err != nil {
+				c.Fail("File '%s' (expanded to '%s') does not exists: %v", fileName, expandedFileName, err)
+				return
+			}
+
+			r, err := c.ParseResource(expandedFileName)
+			if err != nil {
+				c.Fail("Failed to parse file '%s' (expanded to '%s'): %v", fileName, expandedFileName, err)
+				return
+			}
+
+			if err = r.Visit(ReplaceResource); err != nil {
+				c.Fail("Failed to replace resource from file '%s' (expanded to '%s'): %v", fileName, expandedFileName, err)
+				return
+			}
+		})
+
+		c.When(`^I create the following resources:$`, func(data string) {
+			r, err := c.ParseResourceData(data)
+			if err != nil {
+				c.Fail("Failed to parse resources: %v\n%s", err, data)
+				return
+			}
+
+			if err = r.Visit(CreateResource); err != nil {
+				c.Fail("Failed to create resources: %v\n%s", err, data)
+				return
+			}
+		})
+
+		c.When(`^I apply the following resources:$`, func(data string) {
+			r, err := c.ParseResourceData(data)
+			if err != nil {
+				c.Fail("Failed to parse resources: %v\n%s", err, data)
+				return
+			}
+
+			if err = r.Visit(ApplyResource); err != nil {
+				c.Fail("Failed to apply resources: %v\n%s", err, data)
+				return
+			}
+		})
+
 		c.When(`^I delete all resources with "(.+?)"$`, func(selector string) {
 			err := c.DeleteResourcesBySelector(selector)
 			if err != nil {
@@ -63,6 +144,22 @@ func init() {
 			}
 		})
 
+		c.When(`^I delete all "(.+?)" resources$`, func(resourceTypes string) {
+			err := c.DeleteAllResourcesOfTypes(strings.Split(resourceTypes, ","))
+			if err != nil {
+				c.Fail("Failed to delete all '%s' resources: %v", resourceTypes, err)
+				return
+			}
+		})
+
+		c.When(`^I delete the "(.+?)" "(.+?)"$`, func(kind string, name string) {
+			err := c.DeleteResource(kind, name)
+			if err != nil {
+				c.Fail("Failed to delete %s '%s': %v", kind, name, err)
+				return
+			}
+		})
+
 		c.When(`^I delete all resources$`, func() {
 			err := c.DeleteAllResources()
 			if err != nil {
@@ -70,26 +167,175 @@ func init() {
 				return
 			}
 		})
+
+		c.Then(`^the "(.+?)" "(.+?)" should have "(.+?)" equal to "([^"]*)"$`, func(kind string, name string, fieldPath string, expectedValue string) {
+			info, err := c.GetResource(kind, name)
+			if err != nil {
+				c.Fail("Failed to get %s '%s': %v", kind, name, err)
+				return
+			}
+
+			value, err := ResourceFieldValue(info, fieldPath)
+			if err != nil {
+				c.Fail("Failed to get '%s' of %s '%s': %v", fieldPath, kind, name, err)
+				return
+			}
+
+			actualValue, err := formatJSONValue(value)
+			if err != nil {
+				c.Fail("Failed to format '%s' of %s '%s': %v", fieldPath, kind, name, err)
+				return
+			}
+
+			assert.Equal(c.T, expectedValue, actualValue, "Unexpected value for '%s' of %s '%s'", fieldPath, kind, name)
+		})
+
+		c.Then(`^the "(.+?)" "(.+?)" should have label "(.+?)" = "([^"]*)"$`, func(kind string, name string, key string, expectedValue string) {
+			info, err := c.GetResource(kind, name)
+			if err != nil {
+				c.Fail("Failed to get %s '%s': %v", kind, name, err)
+				return
+			}
+
+			labels, err := info.Mapping.MetadataAccessor.Labels(info.Object)
+			if err != nil {
+				c.Fail("Failed to get the labels of %s '%s': %v", kind, name, err)
+				return
+			}
+
+			value, found := labels[key]
+			if !found {
+				c.Fail("The %s '%s' has no label '%s'. Labels: %v", kind, name, key, labels)
+				return
+			}
+
+			assert.Equal(c.T, expectedValue, value, "Unexpected value for the label '%s' of %s '%s'", key, kind, name)
+		})
+
+		c.Then(`^the "(.+?)" "(.+?)" should not have label "(.+?)"$`, func(kind string, name string, key string) {
+			info, err := c.GetResource(kind, name)
+			if err != nil {
+				c.Fail("Failed to get %s '%s': %v", kind, name, err)
+				return
+			}
+
+			labels, err := info.Mapping.MetadataAccessor.Labels(info.Object)
+			if err != nil {
+				c.Fail("Failed to get the labels of %s '%s': %v", kind, name, err)
+				return
+			}
+
+			if value, found := labels[key]; found {
+				c.Fail("The %s '%s' has the label '%s' with value '%s'", kind, name, key, value)
+				return
+			}
+		})
+
+		c.Then(`^the "(.+?)" "(.+?)" should have annotation "(.+?)" = "([^"]*)"$`, func(kind string, name string, key string, expectedValue string) {
+			info, err := c.GetResource(kind, name)
+			if err != nil {
+				c.Fail("Failed to get %s '%s': %v", kind, name, err)
+				return
+			}
+
+			annotations, err := info.Mapping.MetadataAccessor.Annotations(info.Object)
+			if err != nil {
+				c.Fail("Failed to get the annotations of %s '%s': %v", kind, name, err)
+				return
+			}
+
+			value, found := annotations[key]
+			if !found {
+				c.Fail("The %s '%s' has no annotation '%s'. Annotations: %v", kind, name, key, annotations)
+				return
+			}
+
+			assert.Equal(c.T, expectedValue, value, "Unexpected value for the annotation '%s' of %s '%s'", key, kind, name)
+		})
+
+		c.Then(`^the "(.+?)" "(.+?)" should not have annotation "(.+?)"$`, func(kind string, name string, key string) {
+			info, err := c.GetResource(kind, name)
+			if err != nil {
+				c.Fail("Failed to get %s '%s': %v", kind, name, err)
+				return
+			}
+
+			annotations, err := info.Mapping.MetadataAccessor.Annotations(info.Object)
+			if err != nil {
+				c.Fail("Failed to get the annotations of %s '%s': %v", kind, name, err)
+				return
+			}
+
+			if value, found := annotations[key]; found {
+				c.Fail("The %s '%s' has the annotation '%s' with value '%s'", kind, name, key, value)
+				return
+			}
+		})
 	})
 }
 
-// DeleteAllResources deletes all resources
+// SaveConfig defines whether the configuration of the created and replaced resources
+// is stored in the last applied configuration annotation, so that they can be applied later
+// (the resources created by ApplyResource always store it)
+var SaveConfig = false
+
+// DeletableResourceTypes are the types of the resources deleted by DeleteAllResources and DeleteResourcesBySelector
+var DeletableResourceTypes = []string{"template", "buildconfig", "build", "imagestream", "deploymentconfig", "replicationcontroller", "pod", "service", "route", "pvc"}
+
+// DeleteGracePeriod is the period of time (in seconds) given to the resources to terminate gracefully when deleted.
+// If negative, the default grace period of each resource is used.
+// It only applies to the kinds deleted with a reaper (deployment configs, replication controllers, pods, services, ...):
+// the other kinds are deleted without delete options, so with their default grace period.
+var DeleteGracePeriod = 0
+
+// deletionTimeout is the maximum duration to wait for deleted resources to be gone
+const deletionTimeout = defaultWaitTimeout
+
+// DeleteAllResources deletes all resources (of the DeletableResourceTypes)
 func (c *Context) DeleteAllResources() error {
-	return c.deleteResourcesBySelect(func(builder *resource.Builder) *resource.Builder {
+	return c.DeleteAllResourcesOfTypes(DeletableResourceTypes)
+}
+
+// DeleteAllResourcesOfTypes deletes all resources of the given types
+func (c *Context) DeleteAllResourcesOfTypes(resourceTypes []string) error {
+	return c.deleteResourcesBySelect(resourceTypes, func(builder *resource.Builder) *resource.Builder {
 		return builder.SelectAllParam(true)
 	})
 }
 
-// DeleteResourcesBySelector deletes all resources matching the given label selector
+// DeleteResourcesBySelector deletes all resources (of the DeletableResourceTypes) matching the given label selector
 func (c *Context) DeleteResourcesBySelector(selector string) error {
-	return c.deleteResourcesBySelect(func(builder *resource.Builder) *resource.Builder {
+	return c.deleteResourcesBySelect(DeletableResourceTypes, func(builder *resource.Builder) *resource.Builder {
 		return builder.SelectorParam(selector).SelectAllParam(false)
 	})
 }
 
+// DeleteResource deletes the resource of the given kind (or resource type, or alias) with the given name,
+// and waits until it is gone. It returns an error if the resource could not be deleted.
+func (c *Context) DeleteResource(kind string, name string) error {
+	factory, err := c.Factory()
+	if err != nil {
+		return err
+	}
+
+	info, err := c.resourceInfo(kind, name, false)
+	if err != nil {
+		return err
+	}
+
+	if err = deleteResource(factory, info); err != nil {
+		return err
+	}
+
+	if err = c.WaitForDeletion(info, deletionTimeout); err == ErrWaitTimeout {
+		return fmt.Errorf("The %s '%s' still exists after %v", kind, name, deletionTimeout)
+	}
+	return err
+}
+
 type selectFunc func(*resource.Builder) *resource.Builder
 
-func (c *Context) deleteResourcesBySelect(fn selectFunc) error {
+func (c *Context) deleteResourcesBySelect(resourceTypes []string, fn selectFunc) error {
 	factory, err := c.Factory()
 	if err != nil {
 		return err
@@ -109,7 +355,7 @@ func (c *Context) deleteResourcesBySelect(fn selectFunc) error {
 			ContinueOnError().
 			NamespaceParam(namespace).DefaultNamespace().
 			FilenameParam(true).
-			ResourceTypeOrNameArgs(false, "template,buildconfig,build,imagestream,deploymentconfig,replicationcontroller,pod,service,route,pvc").
+			ResourceTypeOrNameArgs(false, strings.Join(resourceTypes, ",")).
 			RequireObject(false)).
 		Flatten().
 		Do()
@@ -118,21 +364,111 @@ func (c *Context) deleteResourcesBySelect(fn selectFunc) error {
 		return err
 	}
 
-	return r.Visit(func(info *resource.Info, err error) error {
+	deleted := []*resource.Info{}
+	err = r.Visit(func(info *resource.Info, err error) error {
 		if err != nil {
 			return err
 		}
-		reaper, err := factory.Reaper(info.Mapping)
-		if err != nil {
-			if kubectl.IsNoSuchReaperError(err) {
-				return resource.NewHelper(info.Client, info.Mapping).Delete(info.Namespace, info.Name)
+		// the resource may already have been deleted along with another one (for example the pods of a replication controller)
+		if err = deleteResource(factory, info); err != nil && !kerrors.IsNotFound(err) {
+			return err
+		}
+		deleted = append(deleted, info)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// make sure the resources have been deleted
+	for _, info := range deleted {
+		if err = c.WaitForDeletion(info, deletionTimeout); err != nil {
+			if err == ErrWaitTimeout {
+				return fmt.Errorf("The %s '%s' still exists after %v", info.Mapping.Kind, info.Name, deletionTimeout)
 			}
 			return err
 		}
+	}
 
-		_, err = reaper.Stop(info.Namespace, info.Name, 5*time.Second, api.NewDeleteOptions(int64(0)))
-		return err
-	})
+	return nil
+}
+
+// deleteResource deletes the resource of the given info, using a reaper if the kind has one,
+// with the DeleteGracePeriod.
+func deleteResource(factory *clientcmd.Factory, info *resource.Info) error {
+	reaper, err := factory.Reaper(info.Mapping)
+	if err != nil {
+		if kubectl.IsNoSuchReaperError(err) {
+			err = resource.NewHelper(info.Client, info.Mapping).Delete(info.Namespace, info.Name)
+		}
+	} else {
+		var options *api.DeleteOptions
+		if DeleteGracePeriod >= 0 {
+			options = api.NewDeleteOptions(int64(DeleteGracePeriod))
+		}
+		_, err = reaper.Stop(info.Namespace, info.Name, 5*time.Second, options)
+	}
+
+	return err
+}
+
+// GetResource gets the resource of the given kind (or resource type, or alias) with the given name,
+// and returns its Info (object and REST mapping), or an error.
+// It works for any kind known by the REST mapper of the factory.
+func (c *Context) GetResource(kind string, name string) (*resource.Info, error) {
+	return c.resourceInfo(kind, name, true)
+}
+
+// resourceInfo returns the Info of the resource of the given kind and name.
+// If requireObject is false, the object is not retrieved, and the resource may not exist.
+func (c *Context) resourceInfo(kind string, name string, requireObject bool) (*resource.Info, error) {
+	factory, err := c.Factory()
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, err := c.Namespace()
+	if err != nil {
+		return nil, err
+	}
+
+	mapper, typer := factory.Object()
+	clientMapper := factory.ClientMapperForCommand()
+
+	infos, err := resource.
+		NewBuilder(mapper, typer, clientMapper).
+		NamespaceParam(namespace).DefaultNamespace().
+		ResourceTypeOrNameArgs(false, kind, name).
+		SingleResourceType().
+		RequireObject(requireObject).
+		Flatten().
+		Do().
+		Infos()
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) != 1 {
+		return nil, fmt.Errorf("Expected 1 %s named '%s', but got %d", kind, name, len(infos))
+	}
+
+	return infos[0], nil
+}
+
+// ResourceFieldValue returns the value at the given field path of the given resource.
+// The resource is converted to its versioned (JSON) representation,
+// so the field path uses the JSON field names, for example "spec.replicas" or "spec.template.spec.containers[0].image".
+func ResourceFieldValue(info *resource.Info, fieldPath string) (interface{}, error) {
+	data, err := info.Mapping.Codec.Encode(info.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	var object interface{}
+	if err = json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+
+	return jsonPathValue(object, fieldPath)
 }
 
 // ParseResource parses the resource stored in the given file,
@@ -141,6 +477,22 @@ func (c *Context) deleteResourcesBySelect(fn selectFunc) error {
 // If you need to create resources on openshift, after parsing you will need
 // to use the visitor pattern, and the CreateResource function.
 func (c *Context) ParseResource(fileName string) (*resource.Result, error) {
+	return c.parseResource(func(builder *resource.Builder) *resource.Builder {
+		return builder.FilenameParam(true, fileName)
+	})
+}
+
+// ParseResourceData parses the resource defined (in YAML or JSON) in the given data,
+// and returns the Result or an error.
+//
+// Like ParseResource, you will need to use the visitor pattern to create the resources.
+func (c *Context) ParseResourceData(data string) (*resource.Result, error) {
+	return c.parseResource(func(builder *resource.Builder) *resource.Builder {
+		return builder.Stream(strings.NewReader(data), "inline")
+	})
+}
+
+func (c *Context) parseResource(fn selectFunc) (*resource.Result, error) {
 	factory, err := c.Factory()
 	if err != nil {
 		return nil, err
@@ -154,11 +506,11 @@ func (c *Context) ParseResource(fileName string) (*resource.Result, error) {
 	mapper, typer := factory.Object()
 	clientMapper := factory.ClientMapperForCommand()
 
-	r := resource.
-		NewBuilder(mapper, typer, clientMapper).
-		Schema(validation.NullSchema{}).
-		NamespaceParam(namespace).DefaultNamespace().RequireNamespace().
-		FilenameParam(true, fileName).
+	r := fn(
+		resource.
+			NewBuilder(mapper, typer, clientMapper).
+			Schema(validation.NullSchema{}).
+			NamespaceParam(namespace).DefaultNamespace().RequireNamespace()).
 		Flatten().
 		Do()
 
@@ -180,6 +532,18 @@ func CreateResource(info *resource.Info, err error) error {
 		return err
 	}
 
+	return createResource(info, SaveConfig)
+}
+
+// createResource creates the given resource on openshift,
+// storing its configuration in the last applied configuration annotation if saveConfig is true
+func createResource(info *resource.Info, saveConfig bool) error {
+	if saveConfig {
+		if err := kubectl.UpdateApplyAnnotation(info); err != nil {
+			return err
+		}
+	}
+
 	obj, err := resource.NewHelper(info.Client, info.Mapping).Create(info.Namespace, true, info.Object)
 	if err != nil {
 		return err
@@ -188,3 +552,83 @@ func CreateResource(info *resource.Info, err error) error {
 	info.Refresh(obj, true)
 	return nil
 }
+
+// ApplyResource creates the given resource on openshift, or updates it if it already exists,
+// and returns an error, or nil if successful
+//
+// The update is a three-way strategic merge patch between the last applied configuration
+// (stored in an annotation), the given configuration, and the current configuration on the server.
+// So the fields set by the server or by other clients are kept,
+// and the fields removed from the configuration are removed.
+//
+// Usage: first parse the resource with Context.ParseResource
+// and then use the visitor pattern on the parsed resource:
+//   r.Visit(ApplyResource)
+func ApplyResource(info *resource.Info, err error) error {
+	if err != nil {
+		return err
+	}
+
+	// the modified configuration embeds itself as the last applied configuration annotation
+	modified, err := kubectl.GetModifiedConfiguration(info, true)
+	if err != nil {
+		return err
+	}
+
+	if err = info.Get(); err != nil {
+		if kerrors.IsNotFound(err) {
+			return createResource(info, true)
+		}
+		return err
+	}
+
+	current, err := info.Mapping.Codec.Encode(info.Object)
+	if err != nil {
+		return err
+	}
+
+	original, err := kubectl.GetOriginalConfiguration(info)
+	if err != nil {
+		return err
+	}
+
+	patch, err := strategicpatch.CreateThreeWayMergePatch(original, modified, current, info.VersionedObject, false)
+	if err != nil {
+		return err
+	}
+
+	obj, err := resource.NewHelper(info.Client, info.Mapping).Patch(info.Namespace, info.Name, api.StrategicMergePatchType, patch)
+	if err != nil {
+		return err
+	}
+
+	info.Refresh(obj, true)
+	return nil
+}
+
+// ReplaceResource replaces the given resource on openshift with the given configuration,
+// and returns an error, or nil if successful (the resource needs to exist)
+//
+// Usage: first parse the resource with Context.ParseResource
+// and then use the visitor pattern on the parsed resource:
+//   r.Visit(ReplaceResource)
+func ReplaceResource(info *resource.Info, err error) error {
+	if err != nil {
+		return err
+	}
+
+	// store the configuration in an annotation, so that the resource can be applied later
+	if SaveConfig {
+		if err = kubectl.UpdateApplyAnnotation(info); err != nil {
+			return err
+		}
+	}
+
+	obj, err := resource.NewHelper(info.Client, info.Mapping).Replace(info.Namespace, info.Name, true, info.Object)
+	if err != nil {
+		return err
+	}
+
+	info.Refresh(obj, true)
+	return nil
+}
@@ -1,14 +1,29 @@
 package steps
 
 import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"os"
+	"strings"
 
 	routeapi "github.com/openshift/origin/pkg/route/api"
 
+	kapi "k8s.io/kubernetes/pkg/api"
+
 	"github.com/stretchr/testify/assert"
 )
 
+// RouterAddress is the address (hostname or IP) of the OpenShift router.
+// If defined, the requests to the routes are sent to this address instead of resolving the route host,
+// with the route host used as Host header and TLS server name (SNI).
+// It defaults to the value of the OPENSHIFT_ROUTER env var.
+var RouterAddress = os.Getenv(OpenShiftRouterEnvVarName)
+
 // registers all route related steps
 func init() {
 	RegisterSteps(func(c *Context) {
@@ -35,7 +50,7 @@ func init() {
 			}
 
 			url := routeURL(route)
-			resp, err := c.execHttpGetRequest(url, make(http.Header))
+			resp, err := c.execRouteGetRequest(route, url, make(http.Header))
 			if err != nil {
 				c.Fail("Failed to access the route '%s' at %s: %v", routeName, url, err)
 				return
@@ -60,7 +75,7 @@ func init() {
 			requestHeaders := make(http.Header)
 			requestHeaders.Set("Authorization", "Basic "+basicAuth(login, password))
 
-			resp, err := c.execHttpGetRequest(url, requestHeaders)
+			resp, err := c.execRouteGetRequest(route, url, requestHeaders)
 			if err != nil {
 				c.Fail("Failed to access the route '%s' at %s: %v", routeName, url, err)
 				return
@@ -70,6 +85,196 @@ func init() {
 			resp.Body.Close()
 		})
 
+		c.When(`^I expose the service "([^"]+)" as route "([^"]+)"$`, func(serviceName string, routeName string) {
+			if _, err := c.ExposeService(serviceName, routeName, "", ""); err != nil {
+				c.Fail("Failed to expose the service '%s' as route '%s': %v", serviceName, routeName, err)
+				return
+			}
+		})
+
+		c.When(`^I expose the service "([^"]+)" as route "([^"]+)" with host "([^"]+)"$`, func(serviceName string, routeName string, host string) {
+			if _, err := c.ExposeService(serviceName, routeName, host, ""); err != nil {
+				c.Fail("Failed to expose the service '%s' as route '%s' with host '%s': %v", serviceName, routeName, host, err)
+				return
+			}
+		})
+
+		c.When(`^I expose the service "([^"]+)" as route "([^"]+)" with path "([^"]+)"$`, func(serviceName string, routeName string, path string) {
+			if _, err := c.ExposeService(serviceName, routeName, "", path); err != nil {
+				c.Fail("Failed to expose the service '%s' as route '%s' with path '%s': %v", serviceName, routeName, path, err)
+				return
+			}
+		})
+
+		c.When(`^I expose the service "([^"]+)" as route "([^"]+)" with host "([^"]+)" and path "([^"]+)"$`, func(serviceName string, routeName string, host string, path string) {
+			if _, err := c.ExposeService(serviceName, routeName, host, path); err != nil {
+				c.Fail("Failed to expose the service '%s' as route '%s' with host '%s' and path '%s': %v", serviceName, routeName, host, path, err)
+				return
+			}
+		})
+
+		c.Then(`^(\d+)% of (\d+) requests to the route "(.+?)" should contain "(.+?)" with a tolerance of (\d+)%$`, func(expectedPercent int, requests int, routeName string, expectedText string, tolerance int) {
+			route, err := c.GetRoute(routeName)
+			if err != nil {
+				c.Fail("Failed to get Route '%s': %v", routeName, err)
+				return
+			}
+			if len(route.Spec.Host) == 0 {
+				c.Fail("The Route '%s' has no host !", routeName)
+				return
+			}
+			if requests <= 0 {
+				c.Fail("The number of requests should be positive")
+				return
+			}
+
+			url := routeURL(route)
+
+			// without cookies, so that the requests are not pinned to a single backend by the router sticky sessions
+			client := c.newRouteHttpClient(route)
+			client.Jar = nil
+
+			matches := 0
+			for i := 0; i < requests; i++ {
+				resp, _, err := c.execHttpRequestWithClient(client, "GET", url, make(http.Header), "")
+				if err != nil {
+					c.Fail("Failed to access the route '%s' at %s (request %d/%d): %v", routeName, url, i+1, requests, err)
+					return
+				}
+				data, err := ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
+				if err != nil {
+					c.Fail("Failed to read the response of the route '%s' at %s (request %d/%d): %v", routeName, url, i+1, requests, err)
+					return
+				}
+
+				if strings.Contains(string(data), expectedText) {
+					matches++
+				}
+			}
+
+			observedPercent := matches * 100 / requests
+			diff := observedPercent - expectedPercent
+			if diff < 0 {
+				diff = -diff
+			}
+			assert.True(c.T, diff <= tolerance, "%d%% of the %d requests to the route '%s' contain '%s', but expected %d%% (+/- %d%%)", observedPercent, requests, routeName, expectedText, expectedPercent, tolerance)
+		})
+
+		c.Then(`^the route "(.+?)" should have the TLS termination "(.+?)"$`, func(routeName string, expectedTermination string) {
+			route, err := c.GetRoute(routeName)
+			if err != nil {
+				c.Fail("Failed to get Route '%s': %v", routeName, err)
+				return
+			}
+			if route.Spec.TLS == nil {
+				c.Fail("The Route '%s' is not secured (no TLS config)", routeName)
+				return
+			}
+
+			assert.Equal(c.T, routeapi.TLSTerminationType(expectedTermination), route.Spec.TLS.Termination, "Unexpected TLS termination for the Route '%s'", routeName)
+		})
+
+		c.Then(`^the route "(.+?)" should not be secured$`, func(routeName string) {
+			route, err := c.GetRoute(routeName)
+			if err != nil {
+				c.Fail("Failed to get Route '%s': %v", routeName, err)
+				return
+			}
+
+			assert.Nil(c.T, route.Spec.TLS, "The Route '%s' is secured", routeName)
+		})
+
+		c.Then(`^the route "(.+?)" should have the insecure edge termination policy "(.+?)"$`, func(routeName string, expectedPolicy string) {
+			route, err := c.GetRoute(routeName)
+			if err != nil {
+				c.Fail("Failed to get Route '%s': %v", routeName, err)
+				return
+			}
+			if route.Spec.TLS == nil || route.Spec.TLS.Termination != routeapi.TLSTerminationEdge {
+				c.Fail("The Route '%s' is not edge-terminated", routeName)
+				return
+			}
+
+			policy := route.Spec.TLS.InsecureEdgeTerminationPolicy
+			if len(policy) == 0 {
+				// insecure connections are disabled by default
+				policy = routeapi.InsecureEdgeTerminationPolicyNone
+			}
+
+			assert.True(c.T, strings.EqualFold(expectedPolicy, string(policy)), "The Route '%s' has the insecure edge termination policy '%s', but expected '%s'", routeName, policy, expectedPolicy)
+		})
+
+		c.Then(`^the route "(.+?)" should redirect HTTP to HTTPS$`, func(routeName string) {
+			route, err := c.GetRoute(routeName)
+			if err != nil {
+				c.Fail("Failed to get Route '%s': %v", routeName, err)
+				return
+			}
+			if len(route.Spec.Host) == 0 {
+				c.Fail("The Route '%s' has no host !", routeName)
+				return
+			}
+
+			url := routeURLWithScheme(route, "http")
+			client := c.newRouteHttpClient(route)
+			client.CheckRedirect = doNotFollowRedirects
+
+			resp, _, err := c.execHttpRequestWithClient(client, "GET", url, make(http.Header), "")
+			if err != nil {
+				c.Fail("Failed to access the route '%s' at %s: %v", routeName, url, err)
+				return
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+				c.Fail("The route '%s' at %s should redirect, but the status code is %d", routeName, url, resp.StatusCode)
+				return
+			}
+
+			location := resp.Header.Get("Location")
+			assert.True(c.T, strings.HasPrefix(location, "https://"), "The route '%s' at %s should redirect to HTTPS, but redirects to '%s'", routeName, url, location)
+		})
+
+		c.Then(`^the route "(.+?)" should serve its TLS certificate$`, func(routeName string) {
+			route, err := c.GetRoute(routeName)
+			if err != nil {
+				c.Fail("Failed to get Route '%s': %v", routeName, err)
+				return
+			}
+			if len(route.Spec.Host) == 0 {
+				c.Fail("The Route '%s' has no host !", routeName)
+				return
+			}
+			if route.Spec.TLS == nil || len(route.Spec.TLS.Certificate) == 0 {
+				c.Fail("The Route '%s' has no TLS certificate", routeName)
+				return
+			}
+
+			expectedCert, err := parseCertificate(route.Spec.TLS.Certificate)
+			if err != nil {
+				c.Fail("Failed to parse the TLS certificate of the Route '%s': %v", routeName, err)
+				return
+			}
+
+			url := fmt.Sprintf("https://%s/", route.Spec.Host)
+			resp, err := c.execRouteGetRequest(route, url, make(http.Header))
+			if err != nil {
+				c.Fail("Failed to access the route '%s' at %s: %v", routeName, url, err)
+				return
+			}
+			resp.Body.Close()
+
+			if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+				c.Fail("The route '%s' at %s did not serve any certificate", routeName, url)
+				return
+			}
+
+			servedCert := resp.TLS.PeerCertificates[0]
+			assert.True(c.T, bytes.Equal(expectedCert.Raw, servedCert.Raw), "The route '%s' at %s served the certificate for '%s' (issued by '%s'), but expected the certificate for '%s' (issued by '%s')",
+				routeName, url, servedCert.Subject.CommonName, servedCert.Issuer.CommonName, expectedCert.Subject.CommonName, expectedCert.Issuer.CommonName)
+		})
+
 	})
 }
 
@@ -93,7 +298,76 @@ func (c *Context) GetRoute(routeName string) (*routeapi.Route, error) {
 	return route, nil
 }
 
-// routeURL returns the base URL for the given route
+// ExposeService creates a new Route with the given name, pointing to the given service.
+// The host and path are optional: if no host is given, the router will generate one.
+// The route has the same labels as the service.
+// It returns the created route, or an error.
+func (c *Context) ExposeService(serviceName string, routeName string, host string, path string) (*routeapi.Route, error) {
+	client, _, err := c.Clients()
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, err := c.Namespace()
+	if err != nil {
+		return nil, err
+	}
+
+	service, err := c.GetService(serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	route := &routeapi.Route{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:   routeName,
+			Labels: service.Labels,
+		},
+		Spec: routeapi.RouteSpec{
+			Host: host,
+			Path: path,
+			To: kapi.ObjectReference{
+				Kind: "Service",
+				Name: serviceName,
+			},
+		},
+	}
+
+	return client.Routes(namespace).Create(route)
+}
+
+// execRouteGetRequest executes an HTTP GET request on the given URL of the given route
+// (through the router if a RouterAddress is defined)
+// and returns the response or an error
+func (c *Context) execRouteGetRequest(route *routeapi.Route, url string, headers http.Header) (*http.Response, error) {
+	resp, _, err := c.execHttpRequestWithClient(c.newRouteHttpClient(route), "GET", url, headers, "")
+	return resp, err
+}
+
+// newRouteHttpClient returns a new HTTP client for the given route.
+// If a RouterAddress is defined, the client connects to the router
+// instead of the route host, but still sends the route host as TLS server name (SNI).
+func (c *Context) newRouteHttpClient(route *routeapi.Route) *http.Client {
+	client := c.newHttpClient()
+	if len(RouterAddress) == 0 {
+		return client
+	}
+
+	transport := client.Transport.(*http.Transport)
+	dial := transport.Dial
+	transport.Dial = func(network, addr string) (net.Conn, error) {
+		_, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		return dial(network, net.JoinHostPort(RouterAddress, port))
+	}
+	transport.TLSClientConfig.ServerName = route.Spec.Host
+
+	return client
+}
+
+// routeURL returns the base URL for the given route (including its path, if any)
 // taking care to use the right scheme based on the route TLS config
 func routeURL(route *routeapi.Route) string {
 	scheme := "http"
@@ -105,5 +379,25 @@ func routeURL(route *routeapi.Route) string {
 			scheme = "https"
 		}
 	}
-	return fmt.Sprintf("%s://%s/", scheme, route.Spec.Host)
+
+	return routeURLWithScheme(route, scheme)
+}
+
+// routeURLWithScheme returns the base URL for the given route (including its path, if any)
+// with the given scheme
+func routeURLWithScheme(route *routeapi.Route, scheme string) string {
+	path := route.Spec.Path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, route.Spec.Host, path)
+}
+
+// parseCertificate parses the first certificate of the given PEM-encoded data
+func parseCertificate(data string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("No PEM data found")
+	}
+	return x509.ParseCertificate(block.Bytes)
 }
@@ -0,0 +1,27 @@
+package steps
+
+import (
+	"testing"
+
+	routeapi "github.com/openshift/origin/pkg/route/api"
+)
+
+func TestRouteURL(t *testing.T) {
+	tests := []struct {
+		spec        routeapi.RouteSpec
+		expectedURL string
+	}{
+		{routeapi.RouteSpec{Host: "hello.example.com"}, "http://hello.example.com/"},
+		{routeapi.RouteSpec{Host: "hello.example.com", Path: "/api"}, "http://hello.example.com/api"},
+		{routeapi.RouteSpec{Host: "hello.example.com", Path: "api"}, "http://hello.example.com/api"},
+		{routeapi.RouteSpec{Host: "hello.example.com", TLS: &routeapi.TLSConfig{Termination: routeapi.TLSTerminationEdge}}, "https://hello.example.com/"},
+		{routeapi.RouteSpec{Host: "hello.example.com", Path: "/api", TLS: &routeapi.TLSConfig{Termination: routeapi.TLSTerminationReencrypt}}, "https://hello.example.com/api"},
+	}
+
+	for _, test := range tests {
+		url := routeURL(&routeapi.Route{Spec: test.spec})
+		if url != test.expectedURL {
+			t.Errorf("routeURL(%+v): expected '%s', but got '%s'", test.spec, test.expectedURL, url)
+		}
+	}
+}
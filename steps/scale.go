@@ -0,0 +1,119 @@
+package steps
+
+import (
+	"time"
+
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	deployutil "github.com/openshift/origin/pkg/deploy/util"
+
+	"k8s.io/kubernetes/pkg/kubectl"
+	"k8s.io/kubernetes/pkg/labels"
+)
+
+// registers all scaling related steps
+func init() {
+	RegisterSteps(func(c *Context) {
+
+		c.When(`^I scale the deploymentconfig "(.+?)" to (\d+) replicas?$`, func(dcName string, replicas int) {
+			if err := c.Scale("DeploymentConfig", dcName, replicas); err != nil {
+				c.Fail("Failed to scale Deployment Config '%s' to %d replicas: %v", dcName, replicas, err)
+				return
+			}
+		})
+
+		c.When(`^I scale the replicationcontroller "(.+?)" to (\d+) replicas?$`, func(rcName string, replicas int) {
+			if err := c.Scale("ReplicationController", rcName, replicas); err != nil {
+				c.Fail("Failed to scale Replication Controller '%s' to %d replicas: %v", rcName, replicas, err)
+				return
+			}
+		})
+
+		c.Then(`^the deploymentconfig "(.+?)" should have (\d+) ready pods? within "(.+?)"$`, func(dcName string, expectedReadyPods int, timeout string) {
+			timeoutDuration, err := time.ParseDuration(timeout)
+			if err != nil {
+				c.Fail("Failed to parse duration '%s': %v", timeout, err)
+				return
+			}
+
+			dc, err := c.GetDeploymentConfig(dcName)
+			if err != nil {
+				c.Fail("Failed to get Deployment Config '%s': %v", dcName, err)
+				return
+			}
+
+			latestDeploymentName := deployutil.LatestDeploymentNameForConfig(dc)
+			deploymentSelector := labels.Set{deployapi.DeploymentLabel: latestDeploymentName}.AsSelector()
+
+			readyPods, err := c.WaitForReadyPods(deploymentSelector, expectedReadyPods, timeoutDuration)
+			if err != nil {
+				c.Fail("Failed to get pods of the deployment '%s': %v", latestDeploymentName, err)
+				return
+			}
+
+			if readyPods != expectedReadyPods {
+				c.Fail("The deployment '%s' has %d ready pods after %s, but expected %d ready pods", latestDeploymentName, readyPods, timeout, expectedReadyPods)
+				return
+			}
+		})
+
+		c.Then(`^the replicationcontroller "(.+?)" should have (\d+) ready pods? within "(.+?)"$`, func(rcName string, expectedReadyPods int, timeout string) {
+			timeoutDuration, err := time.ParseDuration(timeout)
+			if err != nil {
+				c.Fail("Failed to parse duration '%s': %v", timeout, err)
+				return
+			}
+
+			rc, err := c.GetReplicationController(rcName)
+			if err != nil {
+				c.Fail("Failed to get Replication Controller '%s': %v", rcName, err)
+				return
+			}
+
+			rcSelector := labels.Set(rc.Spec.Selector).AsSelector()
+
+			readyPods, err := c.WaitForReadyPods(rcSelector, expectedReadyPods, timeoutDuration)
+			if err != nil {
+				c.Fail("Failed to get pods of the Replication Controller '%s': %v", rcName, err)
+				return
+			}
+
+			if readyPods != expectedReadyPods {
+				c.Fail("The Replication Controller '%s' has %d ready pods after %s, but expected %d ready pods", rcName, readyPods, timeout, expectedReadyPods)
+				return
+			}
+		})
+
+	})
+}
+
+// Scale scales the resource of the given kind (DeploymentConfig, ReplicationController, ...)
+// and name to the given number of replicas.
+//
+// It does not wait for the pods to be ready, see WaitForReadyPods.
+// It returns an error if the resource could not be scaled.
+func (c *Context) Scale(kind string, name string, replicas int) error {
+	factory, err := c.Factory()
+	if err != nil {
+		return err
+	}
+
+	namespace, err := c.Namespace()
+	if err != nil {
+		return err
+	}
+
+	mapper, _ := factory.Object()
+	mapping, err := mapper.RESTMapping(kind)
+	if err != nil {
+		return err
+	}
+
+	scaler, err := factory.Scaler(mapping)
+	if err != nil {
+		return err
+	}
+
+	// retry on resource version conflicts
+	retry := kubectl.NewRetryParams(1*time.Second, 30*time.Second)
+	return scaler.Scale(namespace, name, uint(replicas), nil, retry, nil)
+}
@@ -0,0 +1,176 @@
+package steps
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openshift/origin/pkg/api/latest"
+
+	"k8s.io/kubernetes/pkg/api/validation"
+	utilerrors "k8s.io/kubernetes/pkg/util/errors"
+	"k8s.io/kubernetes/pkg/util/yaml"
+)
+
+// SchemaCacheDir is the directory where the swagger schemas of the server are cached,
+// so that the files can also be validated in @offline scenarios
+var SchemaCacheDir = filepath.Join(os.Getenv("HOME"), ".openshift-cucumber", "schema")
+
+// schemaFileName is the name of the cached swagger schema files
+const schemaFileName = "schema.json"
+
+// registers all schema related steps
+func init() {
+	RegisterSteps(func(c *Context) {
+
+		c.Then(`^the file "(.+?)" should be valid$`, func(fileName string) {
+			expandedFileName := os.ExpandEnv(fileName)
+			if expandedFileName == "" {
+				c.Fail("File name '%s' (expanded to '%s') is empty !", fileName, expandedFileName)
+				return
+			}
+
+			data, err := ioutil.ReadFile(expandedFileName)
+			if err != nil {
+				c.Fail("Failed to read file '%s' (expanded to '%s'): %v", fileName, expandedFileName, err)
+				return
+			}
+
+			if errs := c.ValidateResourceData(data); len(errs) > 0 {
+				messages := []string{}
+				for _, err := range errs {
+					messages = append(messages, fmt.Sprintf("- %v", err))
+				}
+				c.Fail("The file '%s' (expanded to '%s') is not valid:\n%s", fileName, expandedFileName, strings.Join(messages, "\n"))
+				return
+			}
+		})
+
+	})
+}
+
+// ValidateResourceData validates the given resource (in YAML or JSON) against the swagger schema of the server.
+// The items of a List are validated one by one, against the schema of their own API.
+//
+// The schemas are cached in the SchemaCacheDir: in @offline scenarios, only the cached schemas are used.
+// It returns the list of the validation errors (one for each invalid field, with its path).
+func (c *Context) ValidateResourceData(data []byte) []error {
+	jsonData, err := yaml.ToJSON(data)
+	if err != nil {
+		return []error{err}
+	}
+
+	var obj map[string]interface{}
+	if err = json.Unmarshal(jsonData, &obj); err != nil {
+		return []error{err}
+	}
+
+	kind, _ := obj["kind"].(string)
+	if !strings.HasSuffix(kind, "List") {
+		return c.validateObject(obj, "")
+	}
+
+	items, ok := obj["items"].([]interface{})
+	if !ok {
+		return []error{fmt.Errorf("items of the %s is not an array", kind)}
+	}
+
+	errs := []error{}
+	for i, item := range items {
+		itemObj, ok := item.(map[string]interface{})
+		if !ok {
+			errs = append(errs, fmt.Errorf("items[%d] is not an object", i))
+			continue
+		}
+		errs = append(errs, c.validateObject(itemObj, fmt.Sprintf("items[%d].", i))...)
+	}
+	return errs
+}
+
+// validateObject validates the given object against the swagger schema of its API,
+// and returns the validation errors, prefixed with the given field path prefix
+func (c *Context) validateObject(obj map[string]interface{}, prefix string) []error {
+	apiVersion, _ := obj["apiVersion"].(string)
+	kind, _ := obj["kind"].(string)
+	if len(apiVersion) == 0 || len(kind) == 0 {
+		return []error{fmt.Errorf("%sapiVersion and %skind should be set", prefix, prefix)}
+	}
+
+	// the kubernetes and openshift objects are described by different APIs
+	apiPrefix := "api"
+	switch {
+	case strings.Contains(apiVersion, "/"):
+		apiPrefix = "apis"
+	case latest.OriginKind(kind, apiVersion):
+		apiPrefix = "oapi"
+	}
+
+	schema, err := c.getSwaggerSchema(apiPrefix, apiVersion)
+	if err != nil {
+		return []error{fmt.Errorf("%sFailed to get the schema for %s %s: %v", prefix, apiVersion, kind, err)}
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return []error{err}
+	}
+
+	err = schema.ValidateBytes(data)
+	if err == nil {
+		return nil
+	}
+
+	errs := []error{err}
+	if aggregate, ok := err.(utilerrors.Aggregate); ok {
+		errs = aggregate.Errors()
+	}
+	if len(prefix) > 0 {
+		for i := range errs {
+			errs[i] = fmt.Errorf("%s%v", prefix, errs[i])
+		}
+	}
+	return errs
+}
+
+// getSwaggerSchema returns the swagger schema of the given API (prefix and version).
+// The schema is retrieved from the server and cached in the SchemaCacheDir,
+// or read from the cache in @offline scenarios.
+func (c *Context) getSwaggerSchema(apiPrefix string, apiVersion string) (validation.Schema, error) {
+	cacheFile := filepath.Join(SchemaCacheDir, apiPrefix, apiVersion, schemaFileName)
+
+	var data []byte
+	if c.offline {
+		var err error
+		if data, err = ioutil.ReadFile(cacheFile); err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("No cached schema in %s: run a scenario validating the same kind of files against a server first", cacheFile)
+			}
+			return nil, err
+		}
+	} else {
+		_, kclient, err := c.Clients()
+		if err != nil {
+			return nil, err
+		}
+
+		data, err = kclient.RESTClient.Get().
+			AbsPath("/swaggerapi", apiPrefix, apiVersion).
+			Do().
+			Raw()
+		if err != nil {
+			return nil, err
+		}
+
+		if err = os.MkdirAll(filepath.Dir(cacheFile), 0755); err != nil {
+			return nil, err
+		}
+		if err = ioutil.WriteFile(cacheFile, data, 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	return validation.NewSwaggerSchemaFromBytes(data)
+}
@@ -54,6 +54,22 @@ func init() {
 			}
 		})
 
+		c.When(`^I create a secret "(.+?)" with the following data:$`, func(secretName string, rows [][]string) {
+			data := make(map[string]string)
+			for _, row := range rows {
+				if len(row) != 2 {
+					c.Fail("Each row of the data should have 2 columns (key and value), but got %v", row)
+					return
+				}
+				data[row[0]] = row[1]
+			}
+
+			if _, err := c.CreateSecretFromData(secretName, data); err != nil {
+				c.Fail("Failed to create secret '%s': %v", secretName, err)
+				return
+			}
+		})
+
 		c.Then(`I should have a secret "(.+?)" of type "(.+?)" with a key "(.+?)"`, func(secretName string, secretType string, keyName string) {
 			secret, err := c.GetSecret(secretName)
 			if err != nil {
@@ -150,6 +166,39 @@ func (c *Context) CreateSecret(secretName string, secretType kapi.SecretType, so
 	return secret, nil
 }
 
+// CreateSecretFromData creates a new opaque secret with the given name and data (key/value pairs).
+//
+// It returns the newly created secret, or an error.
+func (c *Context) CreateSecretFromData(secretName string, data map[string]string) (*kapi.Secret, error) {
+	_, kclient, err := c.Clients()
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, err := c.Namespace()
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &kapi.Secret{
+		ObjectMeta: kapi.ObjectMeta{
+			Name: secretName,
+		},
+		Type: kapi.SecretTypeOpaque,
+		Data: make(map[string][]byte),
+	}
+	for key, value := range data {
+		secret.Data[key] = []byte(value)
+	}
+
+	secret, err = kclient.Secrets(namespace).Create(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
 // AddSecretToServiceAccount adds the secret with the given name to the service account with the given name.
 //
 // Both secret and service account should already exist.
@@ -28,6 +28,11 @@ const (
 	// Name of the env var that contains the OpenShift token
 	// Either use login/password or token
 	OpenShiftTokenEnvVarName = "OPENSHIFT_TOKEN"
+
+	// Name of the env var that contains the address of the OpenShift router
+	// used to access the routes, instead of resolving the routes hosts
+	// example: "10.0.0.1"
+	OpenShiftRouterEnvVarName = "OPENSHIFT_ROUTER"
 )
 
 // StepsRegisterer allows to register steps on a Context
@@ -48,8 +53,15 @@ func RegisterSteps(registerer StepsRegisterer) {
 func init() {
 	RegisterSteps(func(c *Context) {
 
+		// cleanup after each scenario (an empty filter matches all scenarios)
+		// but keep the state set up by the feature's Background (see LoadFeatures)
+		c.After("", func() {
+			c.endUnit()
+		})
+
 		c.Before("@offline", func() {
 			c.setNamespace("offline")
+			c.offline = true
 			c.setFactory(func() *clientcmd.Factory {
 				flags := pflag.NewFlagSet("openshift-factory", pflag.ContinueOnError)
 				return clientcmd.New(flags)
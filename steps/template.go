@@ -1,12 +1,23 @@
 package steps
 
 import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/openshift/origin/pkg/api/latest"
+	"github.com/openshift/origin/pkg/template"
 	templateapi "github.com/openshift/origin/pkg/template/api"
+	"github.com/openshift/origin/pkg/template/generator"
 
 	"k8s.io/kubernetes/pkg/fields"
+	"k8s.io/kubernetes/pkg/kubectl/resource"
 	"k8s.io/kubernetes/pkg/labels"
+	"k8s.io/kubernetes/pkg/runtime"
+	utilerrors "k8s.io/kubernetes/pkg/util/errors"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -80,6 +91,52 @@ func init() {
 			}
 		})
 
+		c.When(`^I process the template "([^\"]+?)"$`, func(templateName string) {
+			if err := c.ProcessTemplate(templateName, map[string]string{}); err != nil {
+				c.Fail("Failed to process the template '%s': %v", templateName, err)
+				return
+			}
+		})
+
+		c.When(`^I process the template "(.+?)" with parameters "(.+?)"$`, func(templateName string, parameters string) {
+			parametersList, err := parseParameters(parameters)
+			if err != nil {
+				c.Fail("Failed to parse parameters '%s': %v", parameters, err)
+				return
+			}
+
+			// expand the parameters values
+			for k, v := range parametersList {
+				parametersList[k] = os.ExpandEnv(v)
+			}
+
+			if err = c.ProcessTemplate(templateName, parametersList); err != nil {
+				c.Fail("Failed to process the template '%s': %v", templateName, err)
+				return
+			}
+		})
+
+		c.Then(`^the processed template should contain a "(.+?)" named "([^"]+)"$`, func(kind string, name string) {
+			if _, err := c.GetProcessedObject(kind, name); err != nil {
+				c.Fail("%v", err)
+				return
+			}
+		})
+
+		c.Then(`^the processed template should contain a "route" named "(.+?)" with host "([^"]*)"$`, func(routeName string, expectedHost string) {
+			object, err := c.GetProcessedObject("route", routeName)
+			if err != nil {
+				c.Fail("%v", err)
+				return
+			}
+
+			var host string
+			if value, err := jsonPathValue(object, "spec.host"); err == nil {
+				host, _ = value.(string)
+			}
+			assert.Equal(c.T, expectedHost, host, "The processed route '%s' has the host '%s', but expected host is '%s'", routeName, host, expectedHost)
+		})
+
 	})
 }
 
@@ -127,3 +184,124 @@ func (c *Context) GetTemplate(templateName string) (*templateapi.Template, error
 
 	return template, nil
 }
+
+// ProcessTemplate processes the template with the given name and parameters,
+// without creating the resulting objects: they are stored in the context,
+// and can be retrieved with GetProcessedObject.
+//
+// If the given name is the path of an existing file, the template is read from this file,
+// so that it can be processed offline. Otherwise, the template is retrieved from openshift.
+// Like oc process, the parameters without a value are generated.
+func (c *Context) ProcessTemplate(templateName string, parameters map[string]string) error {
+	tmpl, err := c.loadTemplate(templateName)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range parameters {
+		param := template.GetParameterByName(tmpl, name)
+		if param == nil {
+			return fmt.Errorf("Unknown parameter '%s' for the template '%s'", name, tmpl.Name)
+		}
+		param.Value = value
+		param.Generate = ""
+	}
+
+	generators := map[string]generator.Generator{
+		"expression": generator.NewExpressionValueGenerator(rand.New(rand.NewSource(time.Now().UnixNano()))),
+	}
+	if errs := template.NewProcessor(generators).Process(tmpl); len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+
+	c.processedObjects = tmpl.Objects
+	return nil
+}
+
+// GetProcessedObject returns the object of the given kind and name
+// from the objects of the last processed template, as unmarshalled JSON,
+// or an error if there is no such object.
+// The kind is case-insensitive, for example "route" or "Route".
+func (c *Context) GetProcessedObject(kind string, name string) (map[string]interface{}, error) {
+	if c.processedObjects == nil {
+		return nil, fmt.Errorf("No template has been processed")
+	}
+
+	kinds := []string{}
+	for _, obj := range c.processedObjects {
+		object, err := processedObjectData(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		objectKind, _ := object["kind"].(string)
+		var objectName string
+		if value, err := jsonPathValue(object, "metadata.name"); err == nil {
+			objectName, _ = value.(string)
+		}
+		if strings.EqualFold(objectKind, kind) && objectName == name {
+			return object, nil
+		}
+		kinds = append(kinds, fmt.Sprintf("%s '%s'", objectKind, objectName))
+	}
+
+	return nil, fmt.Errorf("The processed template does not contain a %s named '%s', but contains: %s", kind, name, strings.Join(kinds, ", "))
+}
+
+// loadTemplate reads the template from the file with the given name if it exists,
+// or gets the template with the given name from openshift
+func (c *Context) loadTemplate(templateName string) (*templateapi.Template, error) {
+	fileName := os.ExpandEnv(templateName)
+	if _, err := os.Stat(fileName); err != nil {
+		return c.GetTemplate(templateName)
+	}
+
+	r, err := c.ParseResource(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var tmpl *templateapi.Template
+	err = r.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			return err
+		}
+		t, ok := info.Object.(*templateapi.Template)
+		if !ok {
+			return fmt.Errorf("The file '%s' contains a %s, but expected a Template", fileName, info.Mapping.Kind)
+		}
+		if tmpl != nil {
+			return fmt.Errorf("The file '%s' contains more than one Template", fileName)
+		}
+		tmpl = t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if tmpl == nil {
+		return nil, fmt.Errorf("The file '%s' does not contain any Template", fileName)
+	}
+
+	return tmpl, nil
+}
+
+// processedObjectData returns the (unmarshalled) JSON representation of the given processed object.
+// The objects of a processed template are usually unstructured,
+// but they can also be typed objects.
+func processedObjectData(obj runtime.Object) (map[string]interface{}, error) {
+	if unstructured, ok := obj.(*runtime.Unstructured); ok {
+		return unstructured.Object, nil
+	}
+
+	data, err := latest.Codec.Encode(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var object map[string]interface{}
+	if err = json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
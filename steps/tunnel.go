@@ -3,74 +3,369 @@ package steps
 import (
 	"fmt"
 	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	kclientapi "k8s.io/kubernetes/pkg/client/unversioned"
 	"k8s.io/kubernetes/pkg/client/unversioned/portforward"
 	"k8s.io/kubernetes/pkg/client/unversioned/remotecommand"
+	"k8s.io/kubernetes/pkg/labels"
 )
 
+// registers all tunnel related steps
+func init() {
+	RegisterSteps(func(c *Context) {
+
+		c.When(`^I open a tunnel "(.+?)" to the pod "(.+?)" on ports? ([\d:, ]+)$`, func(tunnelName string, podName string, portsSpec string) {
+			ports, err := parseTunnelPorts(portsSpec)
+			if err != nil {
+				c.Fail("Failed to parse ports '%s': %v", portsSpec, err)
+				return
+			}
+
+			if _, err = c.OpenTunnelWithPorts(tunnelName, podName, ports); err != nil {
+				c.Fail("Failed to open tunnel %s: %v", tunnelName, err)
+				return
+			}
+		})
+
+		c.When(`^I open a tunnel "(.+?)" to a pod with labels? "(.+?)" on ports? ([\d:, ]+)$`, func(tunnelName string, selector string, portsSpec string) {
+			ports, err := parseTunnelPorts(portsSpec)
+			if err != nil {
+				c.Fail("Failed to parse ports '%s': %v", portsSpec, err)
+				return
+			}
+
+			labelSelector, err := labels.Parse(selector)
+			if err != nil {
+				c.Fail("Failed to parse label selector '%s': %v", selector, err)
+				return
+			}
+
+			pod, err := c.GetRunningPod(labelSelector)
+			if err != nil {
+				c.Fail("Failed to find a running pod with labels '%s': %v", selector, err)
+				return
+			}
+
+			if _, err = c.OpenTunnelWithPorts(tunnelName, pod.Name, ports); err != nil {
+				c.Fail("Failed to open tunnel %s: %v", tunnelName, err)
+				return
+			}
+		})
+
+		c.When(`^I open a tunnel "(.+?)" to a pod of the latest deployment of "(.+?)" on ports ([\d:, ]+)$`, func(tunnelName string, dcName string, portsSpec string) {
+			ports, err := parseTunnelPorts(portsSpec)
+			if err != nil {
+				c.Fail("Failed to parse ports '%s': %v", portsSpec, err)
+				return
+			}
+
+			pod, err := c.GetRunningPodOfLatestDeployment(dcName)
+			if err != nil {
+				c.Fail("Failed to find a running pod of the latest deployment of '%s': %v", dcName, err)
+				return
+			}
+
+			if _, err = c.OpenTunnelWithPorts(tunnelName, pod.Name, ports); err != nil {
+				c.Fail("Failed to open tunnel %s: %v", tunnelName, err)
+				return
+			}
+		})
+
+		c.When(`^I open a tunnel "(.+?)" to the service "(.+?)" on ports? ([\d:, ]+)$`, func(tunnelName string, serviceName string, portsSpec string) {
+			ports, err := parseTunnelPorts(portsSpec)
+			if err != nil {
+				c.Fail("Failed to parse ports '%s': %v", portsSpec, err)
+				return
+			}
+
+			if _, err = c.OpenTunnelToService(tunnelName, serviceName, ports); err != nil {
+				c.Fail("Failed to open tunnel %s to the service '%s': %v", tunnelName, serviceName, err)
+				return
+			}
+		})
+
+	})
+}
+
+// maxRandomLocalPortAttempts is the number of random local ports we try
+// before giving up, in case another process binds the port in the meantime
+const maxRandomLocalPortAttempts = 3
+
+// tunnelReadyTimeout is the maximum duration to wait for a tunnel to be ready
+const tunnelReadyTimeout = 30 * time.Second
+
+// tunnelReconnectInterval is the duration to wait between 2 attempts to re-open a tunnel
+const tunnelReconnectInterval = 2 * time.Second
+
+// TunnelPort is a mapping between a local port and a remote port
+type TunnelPort struct {
+	LocalPort  int
+	RemotePort int
+}
+
+// podTargetResolver returns the name of the pod a tunnel should forward to,
+// and the remote ports (in the same order as the tunnel ports)
+type podTargetResolver func() (podName string, remotePorts []int, err error)
+
 // Tunnel is a wrapper around an HTTP tunnel
 // implemented by port forwarding
 type Tunnel struct {
-	Name      string
+	Name string
+	// LocalPort is the local port of the first port mapping
 	LocalPort int
+	Ports     []TunnelPort
+
 	stopChan  chan struct{}
+	readyChan chan struct{}
+	closeOnce sync.Once
+
+	lock    sync.Mutex
+	podName string
+	err     error
 }
 
 // NewTunnel build a new Tunnel with the given name
 // The tunnel will need to be started with StartForwardingToPod
 func NewTunnel(name string) *Tunnel {
 	return &Tunnel{
-		Name:     name,
-		stopChan: make(chan struct{}, 1),
+		Name:      name,
+		stopChan:  make(chan struct{}),
+		readyChan: make(chan struct{}),
 	}
 }
 
 // StartForwardingToPod starts forwarding requests to the given pod on the given target port
 // If no localPort has been defined on the tunnel, a random available port will be assigned
 // The tunnel is started in the background (using a goroutine), and will need to be stopped with Stop()
-// It returns an error if it can't start the tunnel.
+// It waits until the tunnel is listening on the local port (see Ready),
+// and returns an error if it can't start the tunnel.
 func (tunnel *Tunnel) StartForwardingToPod(podName string, namespace string, targetPort int, restClient *kclientapi.RESTClient, clientConfig *kclientapi.Config) error {
+	tunnel.Ports = []TunnelPort{{LocalPort: tunnel.LocalPort, RemotePort: targetPort}}
+	return tunnel.startForwarding(namespace, restClient, clientConfig, staticPodTarget(podName, tunnel.Ports), false)
+}
+
+// startForwarding starts forwarding requests from the tunnel local ports to the pod returned by the given resolver.
+// Random available ports are assigned to the tunnel ports with no local port.
+// If reconnect is true, the tunnel is re-opened on the pod returned by the resolver
+// when the connection to the current pod is lost (for example if the pod is replaced).
+// It waits until the tunnel is listening on the local ports, and returns an error if it can't start the tunnel.
+func (tunnel *Tunnel) startForwarding(namespace string, restClient *kclientapi.RESTClient, clientConfig *kclientapi.Config, resolve podTargetResolver, reconnect bool) error {
+	if len(tunnel.Ports) == 0 {
+		return fmt.Errorf("No ports defined for the tunnel '%s'", tunnel.Name)
+	}
+
+	podName, remotePorts, err := resolve()
+	if err != nil {
+		return err
+	}
+
+	randomPorts := []int{}
+	for i := range tunnel.Ports {
+		if tunnel.Ports[i].LocalPort == 0 {
+			randomPorts = append(randomPorts, i)
+		}
+	}
+
+	var errChan <-chan error
+	for attempt := 1; ; attempt++ {
+		// the random ports may be bound by someone else between the time we find them
+		// and the time the forwarder listens on them, so retry with other ports
+		for _, i := range randomPorts {
+			port, err := getRandomAvailableLocalPort()
+			if err != nil {
+				return err
+			}
+			tunnel.Ports[i].LocalPort = port
+		}
+
+		var listenFailed bool
+		errChan, listenFailed, err = tunnel.forward(podName, remotePorts, namespace, restClient, clientConfig)
+		if err == nil {
+			break
+		}
+		if !listenFailed || len(randomPorts) == 0 || attempt >= maxRandomLocalPortAttempts {
+			return err
+		}
+	}
+
+	tunnel.LocalPort = tunnel.Ports[0].LocalPort
+	tunnel.setPodName(podName)
+	close(tunnel.readyChan)
+
+	go tunnel.watch(errChan, namespace, restClient, clientConfig, resolve, reconnect)
+
+	return nil
+}
+
+// forward starts forwarding the tunnel local ports to the given remote ports of the given pod.
+// It waits until the forwarder is listening, and returns an error if it is not ready.
+// If the forwarder could not listen on the local ports (for example if they are already bound),
+// listenFailed is true and it is safe to try again with other local ports.
+// Once ready, the returned channel will receive the result of the forwarding when it stops:
+// an error if the connection to the pod is lost, or nil if the tunnel has been closed.
+// If the forwarder is not ready in time, it is stopped so that it releases the local ports.
+func (tunnel *Tunnel) forward(podName string, remotePorts []int, namespace string, restClient *kclientapi.RESTClient, clientConfig *kclientapi.Config) (<-chan error, bool, error) {
 	req := restClient.Post().
 		Resource("pods").
 		Namespace(namespace).
 		Name(podName).
 		SubResource("portforward")
 
-	if tunnel.LocalPort == 0 {
-		port, err := getRandomAvailableLocalPort()
-		if err != nil {
-			return err
-		}
-		tunnel.LocalPort = port
+	dialer, err := remotecommand.NewExecutor(clientConfig, "POST", req.URL())
+	if err != nil {
+		return nil, false, err
 	}
 
-	port := fmt.Sprintf("%v:%v", tunnel.LocalPort, targetPort)
-	ports := []string{port}
+	ports := []string{}
+	for i, port := range tunnel.Ports {
+		ports = append(ports, fmt.Sprintf("%v:%v", port.LocalPort, remotePorts[i]))
+	}
 
-	dialer, err := remotecommand.NewExecutor(clientConfig, "POST", req.URL())
-	if err != nil {
-		return err
+	// each attempt has its own stop channel, closed when the tunnel is closed
+	// or when this attempt is given up
+	stopChan := make(chan struct{})
+	var stopOnce sync.Once
+	stop := func() {
+		stopOnce.Do(func() {
+			close(stopChan)
+		})
 	}
+	go func() {
+		select {
+		case <-tunnel.stopChan:
+			stop()
+		case <-stopChan:
+		}
+	}()
 
-	fw, err := portforward.New(dialer, ports, tunnel.stopChan)
+	fw, err := portforward.New(dialer, ports, stopChan)
 	if err != nil {
-		return err
+		stop()
+		return nil, false, err
 	}
 
-	go func(localPort int) {
-		err = fw.ForwardPorts()
-		if err != nil {
-			fmt.Printf("Failed to forward localPort %v to remotePort %v on pod %s: %v\n", localPort, targetPort, podName, err)
+	errChan := make(chan error, 1)
+	go func() {
+		err := fw.ForwardPorts()
+		// the forwarder also returns nil when the connection to the pod is lost
+		if err == nil && !tunnel.isClosed() {
+			err = fmt.Errorf("lost connection to pod %s", podName)
 		}
-	}(tunnel.LocalPort)
+		stop()
+		errChan <- err
+	}()
 
-	return nil
+	select {
+	case <-fw.Ready:
+		return errChan, false, nil
+	case err = <-errChan:
+		if err == nil {
+			err = fmt.Errorf("Forwarding of ports %v to pod %s stopped before being ready", ports, podName)
+		}
+		return nil, isListenError(err), err
+	case <-time.After(tunnelReadyTimeout):
+		stop()
+		return nil, false, fmt.Errorf("Forwarding of ports %v to pod %s was not ready after %v", ports, podName, tunnelReadyTimeout)
+	}
+}
+
+// watch waits until the forwarding stops, and records the error (if any).
+// If the tunnel has not been closed and reconnect is true, it re-opens the tunnel
+// on the pod returned by the given resolver, until the tunnel is closed.
+func (tunnel *Tunnel) watch(errChan <-chan error, namespace string, restClient *kclientapi.RESTClient, clientConfig *kclientapi.Config, resolve podTargetResolver, reconnect bool) {
+	for {
+		err := <-errChan
+		if tunnel.isClosed() {
+			return
+		}
+
+		tunnel.setErr(fmt.Errorf("Failed to forward ports %v to pod %s: %v", tunnel.Ports, tunnel.PodName(), err))
+		if !reconnect {
+			return
+		}
+
+		for errChan = nil; errChan == nil; {
+			select {
+			case <-tunnel.stopChan:
+				return
+			case <-time.After(tunnelReconnectInterval):
+			}
+
+			podName, remotePorts, err := resolve()
+			if err != nil {
+				tunnel.setErr(fmt.Errorf("Failed to re-open the tunnel: %v", err))
+				continue
+			}
+
+			errChan, _, err = tunnel.forward(podName, remotePorts, namespace, restClient, clientConfig)
+			if err != nil {
+				tunnel.setErr(fmt.Errorf("Failed to re-open the tunnel to pod %s: %v", podName, err))
+				continue
+			}
+
+			tunnel.setPodName(podName)
+			tunnel.setErr(nil)
+		}
+	}
+}
+
+// isListenError returns true if the given forwarding error is caused
+// by the forwarder not being able to listen on the local ports
+func isListenError(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "Unable to listen on")
+}
+
+// Ready returns a channel that is closed once the tunnel is listening on its local ports
+func (tunnel *Tunnel) Ready() <-chan struct{} {
+	return tunnel.readyChan
+}
+
+// PodName returns the name of the pod the tunnel is currently forwarding to
+func (tunnel *Tunnel) PodName() string {
+	tunnel.lock.Lock()
+	defer tunnel.lock.Unlock()
+	return tunnel.podName
+}
+
+func (tunnel *Tunnel) setPodName(podName string) {
+	tunnel.lock.Lock()
+	defer tunnel.lock.Unlock()
+	tunnel.podName = podName
+}
+
+// Err returns the error that stopped the forwarding (if any), or nil
+func (tunnel *Tunnel) Err() error {
+	tunnel.lock.Lock()
+	defer tunnel.lock.Unlock()
+	return tunnel.err
+}
+
+func (tunnel *Tunnel) setErr(err error) {
+	tunnel.lock.Lock()
+	defer tunnel.lock.Unlock()
+	tunnel.err = err
+}
+
+// LocalPortFor returns the local port mapped to the given remote port, or 0 if the port is not mapped
+func (tunnel *Tunnel) LocalPortFor(remotePort int) int {
+	for _, port := range tunnel.Ports {
+		if port.RemotePort == remotePort {
+			return port.LocalPort
+		}
+	}
+	return 0
 }
 
 // StopForwarding stop forwarding to the pod, and close the tunnel.
+// It is safe to call it multiple times.
 func (tunnel *Tunnel) StopForwarding() {
-	close(tunnel.stopChan)
+	tunnel.closeOnce.Do(func() {
+		close(tunnel.stopChan)
+	})
 }
 
 // Close is an alias of StopForwarding
@@ -78,9 +373,68 @@ func (tunnel *Tunnel) Close() {
 	tunnel.StopForwarding()
 }
 
+func (tunnel *Tunnel) isClosed() bool {
+	select {
+	case <-tunnel.stopChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// staticPodTarget returns a resolver that always returns the given pod and the remote ports of the given mappings
+func staticPodTarget(podName string, ports []TunnelPort) podTargetResolver {
+	remotePorts := []int{}
+	for _, port := range ports {
+		remotePorts = append(remotePorts, port.RemotePort)
+	}
+	return func() (string, []int, error) {
+		return podName, remotePorts, nil
+	}
+}
+
 // OpenTunnel opens a new Tunnel with the given name, targeting the given pod and port.
+// A random local port is used.
 // It returns the tunnel object (to get the local port), or an error.
 func (c *Context) OpenTunnel(tunnelName string, podName string, targetPort int) (*Tunnel, error) {
+	return c.OpenTunnelOnLocalPort(tunnelName, podName, 0, targetPort)
+}
+
+// OpenTunnelOnLocalPort opens a new Tunnel with the given name, targeting the given pod and port,
+// and listening on the given local port (or a random port if 0).
+// It returns the tunnel object, or an error.
+func (c *Context) OpenTunnelOnLocalPort(tunnelName string, podName string, localPort int, targetPort int) (*Tunnel, error) {
+	return c.OpenTunnelWithPorts(tunnelName, podName, []TunnelPort{{LocalPort: localPort, RemotePort: targetPort}})
+}
+
+// OpenTunnelWithPorts opens a new Tunnel with the given name, targeting the given pod,
+// and forwarding all the given ports (random local ports are used for the ports with no local port).
+// It returns the tunnel object, or an error.
+func (c *Context) OpenTunnelWithPorts(tunnelName string, podName string, ports []TunnelPort) (*Tunnel, error) {
+	return c.openTunnel(tunnelName, ports, staticPodTarget(podName, ports), false)
+}
+
+// OpenTunnelToService opens a new Tunnel with the given name, targeting a ready pod behind the given service.
+// The remote ports of the given mappings are the service ports,
+// they are resolved to the target ports of the pod using the service endpoints.
+// If the pod is replaced, the tunnel is re-opened on another ready pod.
+// It returns the tunnel object, or an error.
+func (c *Context) OpenTunnelToService(tunnelName string, serviceName string, ports []TunnelPort) (*Tunnel, error) {
+	servicePorts := []int{}
+	for _, port := range ports {
+		servicePorts = append(servicePorts, port.RemotePort)
+	}
+
+	resolve := func() (string, []int, error) {
+		return c.resolveServicePod(serviceName, servicePorts)
+	}
+
+	return c.openTunnel(tunnelName, ports, resolve, true)
+}
+
+// openTunnel opens a new Tunnel with the given name and ports, targeting the pod returned by the given resolver
+// If a tunnel with the same name is already open, it is closed first.
+func (c *Context) openTunnel(tunnelName string, ports []TunnelPort, resolve podTargetResolver, reconnect bool) (*Tunnel, error) {
 	_, kclient, err := c.Clients()
 	if err != nil {
 		return nil, err
@@ -96,16 +450,72 @@ func (c *Context) OpenTunnel(tunnelName string, podName string, targetPort int)
 		return nil, err
 	}
 
+	c.CloseTunnel(tunnelName)
+
 	tunnel := NewTunnel(tunnelName)
-	if err = tunnel.StartForwardingToPod(podName, namespace, targetPort, kclient.RESTClient, config); err != nil {
+	tunnel.Ports = append([]TunnelPort{}, ports...)
+	if err = tunnel.startForwarding(namespace, kclient.RESTClient, config, resolve, reconnect); err != nil {
+		tunnel.Close()
 		return nil, err
 	}
 
-	c.tunnels[tunnelName] = *tunnel
+	c.tunnels[tunnelName] = tunnel
 
 	return tunnel, nil
 }
 
+// resolveServicePod finds a ready pod behind the service with the given name,
+// and returns its name and the target ports matching the given service ports
+func (c *Context) resolveServicePod(serviceName string, servicePorts []int) (string, []int, error) {
+	service, err := c.GetService(serviceName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	portNames := []string{}
+	for _, servicePort := range servicePorts {
+		var found bool
+		for _, port := range service.Spec.Ports {
+			if port.Port == servicePort {
+				portNames = append(portNames, port.Name)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", nil, fmt.Errorf("The service '%s' has no port %d", serviceName, servicePort)
+		}
+	}
+
+	ep, err := c.GetEndpoints(serviceName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, subset := range ep.Subsets {
+		targetPorts := []int{}
+		for _, portName := range portNames {
+			for _, port := range subset.Ports {
+				if port.Name == portName {
+					targetPorts = append(targetPorts, port.Port)
+					break
+				}
+			}
+		}
+		if len(targetPorts) != len(portNames) {
+			continue
+		}
+
+		for _, address := range subset.Addresses {
+			if address.TargetRef != nil && address.TargetRef.Kind == "Pod" {
+				return address.TargetRef.Name, targetPorts, nil
+			}
+		}
+	}
+
+	return "", nil, fmt.Errorf("Could not find any ready pod behind the service '%s' for ports %v", serviceName, servicePorts)
+}
+
 // CloseTunnel closes the tunnel with the given name
 func (c *Context) CloseTunnel(tunnelName string) {
 	if tunnel, found := c.tunnels[tunnelName]; found {
@@ -114,6 +524,13 @@ func (c *Context) CloseTunnel(tunnelName string) {
 	}
 }
 
+// CloseAllTunnels closes all the open tunnels
+func (c *Context) CloseAllTunnels() {
+	for tunnelName := range c.tunnels {
+		c.CloseTunnel(tunnelName)
+	}
+}
+
 // getRandomAvailableLocalPort find an available TCP local port
 // and return it - or an error
 func getRandomAvailableLocalPort() (int, error) {
@@ -131,3 +548,32 @@ func getRandomAvailableLocalPort() (int, error) {
 	port := l.Addr().(*net.TCPAddr).Port
 	return port, nil
 }
+
+// parseTunnelPorts parses the given ports specification,
+// a comma-separated list of "remotePort" or "localPort:remotePort"
+func parseTunnelPorts(spec string) ([]TunnelPort, error) {
+	ports := []TunnelPort{}
+	for _, portSpec := range strings.Split(spec, ",") {
+		portSpec = strings.TrimSpace(portSpec)
+		parts := strings.Split(portSpec, ":")
+
+		var port TunnelPort
+		var err error
+		switch len(parts) {
+		case 1:
+			port.RemotePort, err = strconv.Atoi(parts[0])
+		case 2:
+			if port.LocalPort, err = strconv.Atoi(parts[0]); err == nil {
+				port.RemotePort, err = strconv.Atoi(parts[1])
+			}
+		default:
+			err = fmt.Errorf("Port '%s' should match the format 'remotePort' or 'localPort:remotePort'", portSpec)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
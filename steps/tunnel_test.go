@@ -0,0 +1,57 @@
+package steps
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseTunnelPorts(t *testing.T) {
+	tests := []struct {
+		spec          string
+		expectedPorts []TunnelPort
+		expectedError bool
+	}{
+		{"8080", []TunnelPort{{RemotePort: 8080}}, false},
+		{"9000:8080", []TunnelPort{{LocalPort: 9000, RemotePort: 8080}}, false},
+		{"8080, 9000:8443", []TunnelPort{{RemotePort: 8080}, {LocalPort: 9000, RemotePort: 8443}}, false},
+		{"", nil, true},
+		{"http", nil, true},
+		{"9000:", nil, true},
+		{"1:2:3", nil, true},
+	}
+
+	for _, test := range tests {
+		ports, err := parseTunnelPorts(test.spec)
+		if test.expectedError {
+			if err == nil {
+				t.Errorf("parseTunnelPorts(%q): expected an error, but got %v", test.spec, ports)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseTunnelPorts(%q): unexpected error: %v", test.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.expectedPorts, ports) {
+			t.Errorf("parseTunnelPorts(%q): expected %v, but got %v", test.spec, test.expectedPorts, ports)
+		}
+	}
+}
+
+func TestIsListenError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{errors.New("Unable to listen on any of the requested ports: [{8080 80}]"), true},
+		{errors.New("error upgrading connection: unable to upgrade connection: pod does not exist"), false},
+	}
+
+	for _, test := range tests {
+		if listenError := isListenError(test.err); listenError != test.expected {
+			t.Errorf("isListenError(%v): expected %v, but got %v", test.err, test.expected, listenError)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package steps
+
+import (
+	"errors"
+	"time"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kerrors "k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/kubectl/resource"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/watch"
+)
+
+// ErrWaitTimeout is returned by the wait functions
+// when the condition is not met before the timeout
+var ErrWaitTimeout = errors.New("Timed out waiting for the condition")
+
+// defaultWaitTimeout is the timeout of the waits that have no timeout defined by a step
+const defaultWaitTimeout = 5 * time.Minute
+
+// waitPollInterval is the interval between 2 checks of a condition
+// when the resource can't be watched
+const waitPollInterval = 2 * time.Second
+
+// waitResyncInterval is the interval between 2 checks of a condition
+// when the resource is watched, in case an event has been missed
+const waitResyncInterval = 10 * time.Second
+
+// WaitPredicate checks if a resource matches a condition.
+// The object of the given info is nil if the resource does not exist (yet, or anymore).
+// It returns true if the condition is met, or an error to stop waiting.
+type WaitPredicate func(info *resource.Info) (bool, error)
+
+// registers all wait related steps
+func init() {
+	RegisterSteps(func(c *Context) {
+
+		c.Then(`^the "(.+?)" "(.+?)" should have "(.+?)" equal to "([^"]*)" within "(.+?)"$`, func(kind string, name string, fieldPath string, expectedValue string, timeout string) {
+			timeoutDuration, err := time.ParseDuration(timeout)
+			if err != nil {
+				c.Fail("Failed to parse duration '%s': %v", timeout, err)
+				return
+			}
+
+			var lastValue string
+			var lastErr error
+			info, err := c.WaitFor(kind, name, func(info *resource.Info) (bool, error) {
+				if info.Object == nil {
+					lastValue, lastErr = "", errors.New("the resource does not exist")
+					return false, nil
+				}
+
+				// the field may not be set yet, so errors just mean that the condition is not met
+				var value interface{}
+				if value, lastErr = ResourceFieldValue(info, fieldPath); lastErr != nil {
+					return false, nil
+				}
+				if lastValue, lastErr = formatJSONValue(value); lastErr != nil {
+					return false, nil
+				}
+				return lastValue == expectedValue, nil
+			}, timeoutDuration)
+
+			switch {
+			case err == ErrWaitTimeout:
+				involvedObjects := []kapi.ObjectReference{{Kind: info.Mapping.Kind, Name: name}}
+				if lastErr != nil {
+					c.FailWithEvents(involvedObjects, "The %s '%s' does not have '%s' equal to '%s' after %s: %v", kind, name, fieldPath, expectedValue, timeout, lastErr)
+				} else {
+					c.FailWithEvents(involvedObjects, "The %s '%s' does not have '%s' equal to '%s' after %s: its value is '%s'", kind, name, fieldPath, expectedValue, timeout, lastValue)
+				}
+				return
+			case err != nil:
+				c.Fail("Failed to wait for '%s' of %s '%s': %v", fieldPath, kind, name, err)
+				return
+			}
+		})
+
+	})
+}
+
+// WaitFor waits until the given predicate returns true for the resource of the given kind and name,
+// or until the given timeout duration.
+// It works for any kind known by the REST mapper of the factory.
+//
+// The resource is watched, so that the predicate is checked as soon as the resource changes.
+// If the resource can't be watched, it is polled.
+//
+// It returns the info of the resource (with the last object seen, or nil if it does not exist),
+// and ErrWaitTimeout if the condition was not met before the timeout, or another error.
+func (c *Context) WaitFor(kind string, name string, predicate WaitPredicate, timeout time.Duration) (*resource.Info, error) {
+	info, err := c.resourceInfo(kind, name, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return info, c.waitForInfo(info, predicate, timeout)
+}
+
+// WaitForDeletion waits until the resource of the given info does not exist anymore,
+// or until the given timeout duration.
+// It returns ErrWaitTimeout if the resource still exists after the timeout, or another error.
+func (c *Context) WaitForDeletion(info *resource.Info, timeout time.Duration) error {
+	return c.waitForInfo(info, func(info *resource.Info) (bool, error) {
+		return info.Object == nil, nil
+	}, timeout)
+}
+
+// waitForInfo waits until the given predicate returns true for the resource of the given info,
+// or until the given timeout duration. The object of the info is updated with the last object seen.
+func (c *Context) waitForInfo(info *resource.Info, predicate WaitPredicate, timeout time.Duration) error {
+	helper := resource.NewHelper(info.Client, info.Mapping)
+
+	check := func() (bool, error) {
+		var obj runtime.Object
+		err := c.ExecWithExponentialBackoff(func() (err error) {
+			obj, err = helper.Get(info.Namespace, info.Name)
+			if kerrors.IsNotFound(err) {
+				obj, err = nil, nil
+			}
+			return
+		})
+		if err != nil {
+			return false, err
+		}
+
+		info.Object = obj
+		return predicate(info)
+	}
+
+	watchFunc := func() (watch.Interface, error) {
+		return helper.WatchSingle(info.Namespace, info.Name, "")
+	}
+
+	return c.waitUntil(check, watchFunc, timeout)
+}
+
+// waitUntil waits until the given check function returns true, or until the given timeout duration.
+//
+// The check is executed each time the watch returned by the given (optional) watch function receives an event,
+// and periodically in case an event has been missed. If there is no watch function,
+// or if the watch can't be opened (or is closed), the check is executed every waitPollInterval.
+//
+// It returns ErrWaitTimeout if the check did not return true before the timeout,
+// or the error returned by the check.
+func (c *Context) waitUntil(check func() (bool, error), watchFunc func() (watch.Interface, error), timeout time.Duration) error {
+	deadline := time.After(timeout)
+
+	// the watch is opened before the first check, so that we don't miss the changes happening in-between
+	var w watch.Interface
+	var events <-chan watch.Event
+	interval := waitPollInterval
+	if watchFunc != nil {
+		var err error
+		if w, err = watchFunc(); err == nil {
+			defer w.Stop()
+			events = w.ResultChan()
+			interval = waitResyncInterval
+		}
+	}
+
+	for {
+		done, err := check()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case event, ok := <-events:
+			if !ok || event.Type == watch.Error {
+				// fallback to polling
+				w.Stop()
+				events = nil
+				interval = waitPollInterval
+			}
+		case <-time.After(interval):
+		case <-deadline:
+			return ErrWaitTimeout
+		}
+	}
+}